@@ -16,6 +16,9 @@ import useGlobalStore from "@/lib/store";
 export default function HomeScreen() {
   const meals = useGlobalStore((state) => state.meals);
   const setMeals = useGlobalStore((state) => state.setMeals);
+  const dietaryPreferences = useGlobalStore(
+    (state) => state.userProfile.dietaryPreferences,
+  );
 
   const handleSubmitNutrition = async (text: string) => {
     const newMealId = uuid7();
@@ -26,6 +29,7 @@ export default function HomeScreen() {
         text,
         session_id: newSessionId,
         user_id: "user-1",
+        dietary_preferences: dietaryPreferences.map((p) => p.text),
       });
       if (response.status !== 200) {
         throw new Error(`Server error: ${response.data}`);
@@ -1,31 +1,136 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	appadk "github.com/simhozebs/mugo/internal/adk"
 	"github.com/simhozebs/mugo/internal/agents"
+	appconfig "github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/logging"
+	"github.com/simhozebs/mugo/internal/telemetry"
 	"google.golang.org/adk/cmd/launcher/adk"
-	"google.golang.org/adk/cmd/launcher/full"
-	"google.golang.org/adk/server/restapi/services"
-	"log"
-	"os"
+	"google.golang.org/adk/cmd/launcher/console"
+	"google.golang.org/adk/cmd/launcher/universal"
+	"google.golang.org/adk/cmd/launcher/web"
+	"google.golang.org/adk/cmd/launcher/web/a2a"
+	"google.golang.org/adk/cmd/launcher/web/api"
+	"google.golang.org/adk/cmd/launcher/web/webui"
+	"google.golang.org/adk/server/restapi/models"
+	"google.golang.org/adk/session"
 )
 
+// tracedAPILauncher is the ADK REST API sublauncher with request tracing, so
+// agent runs join the traces of the API server requests that started them.
+type tracedAPILauncher struct {
+	web.Sublauncher
+}
+
+func (l tracedAPILauncher) SetupSubrouters(router *mux.Router, config *adk.Config) error {
+	router.Use(telemetry.Middleware("mugo-adk"))
+	router.Use(stateDeltaMiddleware(config.SessionService))
+	return l.Sublauncher.SetupSubrouters(router, config)
+}
+
+// stateDeltaMiddleware applies the state delta of agent runs to their
+// sessions before ADK's /run and /run_sse handlers, which ignore it. The API
+// server sends the user's dietary profile this way on every run.
+func stateDeltaMiddleware(sessionService session.Service) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || !(strings.HasSuffix(r.URL.Path, "/run") || strings.HasSuffix(r.URL.Path, "/run_sse")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			// Malformed requests are left for ADK to reject.
+			var runReq models.RunAgentRequest
+			if json.Unmarshal(body, &runReq) == nil {
+				if err := appadk.ApplyStateDelta(r.Context(), sessionService, runReq); err != nil {
+					slog.ErrorContext(r.Context(), "Failed to apply state delta", "error", err)
+					http.Error(w, "failed to apply state delta", http.StatusInternalServerError)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func main() {
 	ctx := context.Background()
-	weatherAgent, err := agents.Weather()
-	echoAgent, err := agents.NewEchoAgent()
-	nutritionAgent, err := agents.MacroEstimator()
 
-	agentLoader, err := services.NewMultiAgentLoader(weatherAgent, echoAgent, nutritionAgent)
+	// The launcher owns the command line, so the configuration file can only
+	// be given with CONFIG_FILE.
+	cfg, err := appconfig.Load("")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := logging.Setup(cfg.Logging); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	shutdownTelemetry, err := telemetry.Setup(ctx, "mugo-adk", cfg.Telemetry)
 	if err != nil {
-		log.Fatalf("Failed to create agent loader: %v", err)
+		logging.Fatal("Failed to set up telemetry", "error", err)
+	}
+	defer func() {
+		if err := shutdownTelemetry(context.Background()); err != nil {
+			slog.Warn("Failed to flush telemetry", "error", err)
+		}
+	}()
+
+	// Sessions are stored in the database, and the coach reads meal history
+	// through the repositories; without a database, sessions live in memory
+	// and the coach is not available.
+	database, err := db.NewDatabase(ctx, cfg.Database)
+	if err != nil {
+		if cfg.Database.FailFast {
+			logging.Fatal("Failed to connect to database", "error", err)
+		}
+		slog.Warn("Failed to connect to database; sessions will be kept in memory and nutrition_coach agent will not be available", "error", err)
+	} else {
+		defer database.Close()
+	}
+
+	agentLoader, err := agents.NewLoader(cfg.Agents, database)
+	if err != nil {
+		logging.Fatal("Failed to load agents", "error", err)
+	}
+	var sessionService session.Service
+	if database != nil {
+		sessionService = appadk.NewPostgresSessionService(database)
+	} else {
+		sessionService = appadk.NewInMemorySessionService()
 	}
 	config := &adk.Config{
-		AgentLoader: agentLoader,
+		SessionService: sessionService,
+		AgentLoader:    agentLoader,
 	}
 
-	l := full.NewLauncher()
+	// Same as full.NewLauncher, with the REST API traced.
+	l := universal.NewLauncher(
+		console.NewLauncher(),
+		web.NewLauncher(tracedAPILauncher{api.NewLauncher()}, a2a.NewLauncher(), webui.NewLauncher()),
+	)
 	if err = l.Execute(ctx, config, os.Args[1:]); err != nil {
-		log.Fatalf("Run failed: %v\n\n%s", err, l.CommandLineSyntax())
+		fmt.Fprintln(os.Stderr, l.CommandLineSyntax())
+		logging.Fatal("Run failed", "error", err)
 	}
 }
@@ -2,7 +2,9 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,10 +14,16 @@ import (
 	"github.com/danielgtaylor/huma/v2/adapters/humachi"
 	"github.com/go-chi/chi/v5"
 	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/agents"
 	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/jobs"
+	"github.com/simhozebs/mugo/internal/logging"
+	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/ratelimit"
 	"github.com/simhozebs/mugo/internal/routes"
-	"log"
+	"github.com/simhozebs/mugo/internal/telemetry"
+	"google.golang.org/adk/session"
 )
 
 // GreetingOutput represents the greeting operation response.
@@ -26,38 +34,94 @@ type GreetingOutput struct {
 }
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	configPath := flag.String("config", "", "Path of a YAML or TOML configuration file (default $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "Print the effective configuration, with secrets redacted, and exit")
+	flag.Parse()
 
-	// Handle shutdown signals
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		cancel()
-	}()
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *printConfig {
+		fmt.Print(cfg)
+		return
+	}
+
+	if err := logging.Setup(cfg.Logging); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	if err := run(cfg); err != nil {
+		logging.Fatal("Server failed", "error", err)
+	}
+}
 
-	// Initialize ADK client
-	adkServerURL := config.GetADKServerURL()
-	adkClient := adk.NewClient(adkServerURL)
-	log.Printf("ADK client initialized with URL: %s", adkServerURL)
+// run serves the API until SIGINT or SIGTERM, then drains in-flight requests
+// and running jobs before it closes the database and flushes telemetry.
+func run(cfg *config.Config) error {
+	// Handle shutdown signals. A second signal kills the process right away.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Initialize telemetry
+	shutdownTelemetry, err := telemetry.Setup(ctx, "mugo-api", cfg.Telemetry)
+	if err != nil {
+		return fmt.Errorf("failed to set up telemetry: %w", err)
+	}
+	defer func() {
+		if err := shutdownTelemetry(context.Background()); err != nil {
+			slog.Warn("Failed to flush telemetry", "error", err)
+		}
+	}()
 
 	// Initialize database
 	var database *db.Database
-	database, err := db.NewDatabase(ctx)
+	database, err = db.NewDatabase(ctx, cfg.Database)
 	if err != nil {
-		if config.GetFailFastOnDBError() {
-			log.Fatalf("Failed to connect to database: %v", err)
+		if cfg.Database.FailFast {
+			return fmt.Errorf("failed to connect to database: %w", err)
 		}
-		log.Printf("Warning: Failed to connect to database: %v", err)
-		log.Println("Server will run without database persistence")
+		slog.Warn("Failed to connect to database; server will run without database persistence", "error", err)
 	} else {
 		defer database.Close()
-		log.Println("Database connected successfully")
+		slog.Info("Database connected successfully")
+	}
+
+	// Initialize agent runner
+	// Jobs get their own runner, so estimates can run longer than requests.
+	var agentRunner, jobAgentRunner adk.AgentRunner
+	switch mode := cfg.Agents.Runner; mode {
+	case "http":
+		retryPolicy := adk.NewRetryPolicy(cfg.ADK)
+		breaker := adk.NewCircuitBreaker(cfg.ADK.BreakerThreshold, cfg.ADK.BreakerCooldown)
+		agentRunner = adk.NewClientWithPolicy(cfg.ADK.ServerURL, cfg.ADK.Timeout, retryPolicy, breaker)
+		jobAgentRunner = adk.NewClientWithPolicy(cfg.ADK.ServerURL, cfg.Jobs.Timeout, retryPolicy, breaker)
+		slog.Info("ADK client initialized", "url", cfg.ADK.ServerURL)
+	case "embedded":
+		agentLoader, err := agents.NewLoader(cfg.Agents, database)
+		if err != nil {
+			return fmt.Errorf("failed to load agents: %w", err)
+		}
+		var sessionService session.Service
+		if database != nil {
+			sessionService = adk.NewPostgresSessionService(database)
+		} else {
+			sessionService = adk.NewInMemorySessionService()
+		}
+		agentRunner = adk.NewEmbeddedRunner(agentLoader, sessionService)
+		jobAgentRunner = agentRunner
+		slog.Info("Embedded agent runner initialized", "agents", agentLoader.ListAgents())
+	default:
+		return fmt.Errorf("unknown agent runner %q", mode)
 	}
+	agentRunner = adk.Instrument(agentRunner)
+	jobAgentRunner = adk.Instrument(jobAgentRunner)
 
 	r := chi.NewMux()
+	r.Use(telemetry.Middleware("mugo-api"))
+	r.Use(logging.Middleware)
 	api := humachi.New(r, huma.DefaultConfig("Mugo API", "0.1.0"))
+	api.UseMiddleware(telemetry.HumaMiddleware)
 
 	// Register GET /greeting/{name} handler.
 	huma.Get(api, "/greeting/{name}", func(ctx context.Context, input *struct {
@@ -72,30 +136,95 @@ func main() {
 	huma.Post(api, "/conversation", func(ctx context.Context, input *struct {
 		Body routes.ConversationRequest `body:""`
 	}) (*routes.ConversationResponse, error) {
-		return routes.ConversationHandler(ctx, adkClient, &struct {
+		return routes.ConversationHandler(ctx, cfg, agentRunner, &struct {
 			Body routes.ConversationRequest `body:""`
 		}{Body: input.Body})
 	})
 
+	routes.RegisterHealthEndpoints(api, cfg, agentRunner, database)
+
+	// Agent endpoints share one limiter, so a user's calls are counted together.
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewLimiter(cfg.RateLimit, cfg.Idempotency.KeyTTL, database)
+	}
+
 	// Register agent endpoints with database
-	routes.RegisterAgentEndpoints(api, "/agents", adkClient, database)
-	routes.RegisterDebugEndpoints(api, "/debug", adkClient, database)
+	routes.RegisterAgentEndpoints(api, "/agents", cfg, agentRunner, database, limiter)
+	routes.RegisterDebugEndpoints(api, "/debug", cfg, agentRunner, database)
 
 	// Register user and meal endpoints
+	var jobRunner *jobs.Runner
 	if database != nil {
-		routes.RegisterUserEndpoints(api, "/users", database)
-		routes.RegisterMealEndpoints(api, "/meals", database)
-		routes.RegisterAnalyticsEndpoints(api, "/analytics", database)
-		routes.RegisterConversationEndpoints(api, "/conversations", database)
+		routes.RegisterUserEndpoints(api, "/users", cfg, database)
+		routes.RegisterExportEndpoints(api, "/users", database)
+		routes.RegisterImportEndpoints(api, "/users", database)
+		routes.RegisterMealEndpoints(api, "/meals", cfg, database)
+		routes.RegisterAnalyticsEndpoints(api, "/analytics", cfg, agentRunner, database, limiter)
+		routes.RegisterConversationEndpoints(api, "/conversations", cfg, agentRunner, database)
+		routes.RegisterJobEndpoints(api, "/jobs", database)
+		if cfg.Admin.Token != "" {
+			routes.RegisterAdminEndpoints(api, "/admin", cfg, database)
+		}
+
+		jobRunner = jobs.NewRunner(database, cfg.Jobs)
+		jobRunner.Handle(models.JobKindNutritionEstimate, routes.NutritionJobHandler(cfg, jobAgentRunner, database))
+		jobRunner.Handle(models.JobKindUserErasure, routes.UserErasureJobHandler(cfg, jobAgentRunner, database))
+		jobRunner.Start(ctx)
+	} else {
+		// Tell clients why these routes are missing instead of answering 404.
+		unavailable := routes.UnavailableHandler("The database is not connected")
+		for _, prefix := range []string{"/users", "/meals", "/analytics", "/conversations", "/jobs", "/admin"} {
+			r.Mount(prefix, unavailable)
+		}
+		r.Handle("/agents/nutrition/jobs", unavailable)
+	}
+
+	srv := &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
 	}
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8888"
+	serveErr := make(chan error, 1)
+	go func() {
+		// Load ensures the certificate and key are set together.
+		if certFile := cfg.Server.TLSCertFile; certFile != "" {
+			slog.Info("Server starting", "url", fmt.Sprintf("https://localhost:%d", cfg.Server.Port))
+			serveErr <- srv.ListenAndServeTLS(certFile, cfg.Server.TLSKeyFile)
+		} else {
+			slog.Info("Server starting", "url", fmt.Sprintf("http://localhost:%d", cfg.Server.Port))
+			serveErr <- srv.ListenAndServe()
+		}
+	}()
+
+	var serverErr error
+	select {
+	case serverErr = <-serveErr:
+		// The server never started, e.g. because the port is taken.
+	case <-ctx.Done():
+		slog.Info("Shutting down", "timeout", cfg.Server.ShutdownTimeout.String())
 	}
+	// Stops the job workers from claiming jobs.
+	stop()
 
-	log.Printf("Server starting on http://localhost:%s", port)
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("Server error: %v", err)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests and let in-flight ones, agent calls included, finish.
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("Requests still in flight at the shutdown deadline", "error", err)
+	}
+	if jobRunner != nil {
+		if err := jobRunner.Wait(shutdownCtx); err != nil {
+			slog.Warn("Jobs still running at the shutdown deadline", "error", err)
+		}
+	}
+	if serverErr != nil {
+		return fmt.Errorf("server error: %w", serverErr)
 	}
+	slog.Info("Server stopped")
+	return nil
 }
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/agents"
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/eval"
+	"github.com/simhozebs/mugo/internal/logging"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	adkmodels "google.golang.org/adk/server/restapi/models"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+const evalUserID = "eval"
+
+func main() {
+	datasetPath := flag.String("dataset", "eval/datasets/macro_estimator_v1.json", "Path of the golden dataset")
+	mode := flag.String("mode", "inprocess", `How to reach the agent: "inprocess" or "http"`)
+	adkURL := flag.String("adk-url", "", "ADK server URL for http mode (default adk.server_url of the configuration)")
+	outDir := flag.String("out", "eval/reports", "Directory to write the JSON and Markdown reports to")
+	configPath := flag.String("config", "", "Path of a YAML or TOML configuration file (default $CONFIG_FILE)")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if err := logging.Setup(cfg.Logging); err != nil {
+		logging.Fatal("Failed to set up logging", "error", err)
+	}
+	ctx := context.Background()
+
+	dataset, err := eval.LoadDataset(*datasetPath)
+	if err != nil {
+		logging.Fatal("Failed to load dataset", "error", err)
+	}
+
+	var estimate eval.Estimator
+	switch *mode {
+	case "inprocess":
+		estimate, err = inProcessEstimator(cfg.Agents)
+		if err != nil {
+			logging.Fatal("Failed to create in-process estimator", "error", err)
+		}
+	case "http":
+		adkConfig := cfg.ADK
+		if *adkURL != "" {
+			adkConfig.ServerURL = *adkURL
+		}
+		estimate = httpEstimator(adk.NewClient(adkConfig), cfg.Agents.Mapping["nutrition"])
+	default:
+		logging.Fatal("Unknown mode", "mode", *mode)
+	}
+
+	slog.Info("Evaluating dataset",
+		"cases", len(dataset.Cases), "dataset", dataset.Name, "version", dataset.Version, "mode", *mode)
+	results := eval.Run(ctx, dataset, estimate)
+	report := eval.NewReport(dataset, *mode, cfg.Agents.Model.Provider, results)
+
+	if err := os.MkdirAll(*outDir, 0o755); err != nil {
+		logging.Fatal("Failed to create output directory", "error", err)
+	}
+	base := filepath.Join(*outDir, fmt.Sprintf("%s-%s", dataset.Name, dataset.Version))
+	if err := report.WriteJSON(base + ".json"); err != nil {
+		logging.Fatal("Failed to write JSON report", "error", err)
+	}
+	if err := report.WriteMarkdown(base + ".md"); err != nil {
+		logging.Fatal("Failed to write Markdown report", "error", err)
+	}
+
+	s := report.Summary
+	slog.Info("Evaluation finished",
+		"valid", s.Valid, "cases", s.Cases, "mae_kcal", s.MAE.Calories, "mape_kcal", s.MAPE.Calories,
+		"reports", base+".{json,md}")
+}
+
+// httpEstimator runs each case against the ADK server in its own session.
+func httpEstimator(client *adk.Client, appName string) eval.Estimator {
+	return func(ctx context.Context, c eval.Case) (string, error) {
+		result, err := client.RunWithAutoSession(ctx, adkmodels.RunAgentRequest{
+			AppName:   appName,
+			UserId:    evalUserID,
+			SessionId: "eval-" + c.ID + "-" + uuid.NewString(),
+			NewMessage: genai.Content{
+				Role:  string(genai.RoleUser),
+				Parts: []*genai.Part{{Text: c.Text}},
+			},
+		}, nil)
+		if err != nil {
+			return "", err
+		}
+		return result.FinalText, nil
+	}
+}
+
+// inProcessEstimator runs the macro estimator with the ADK runner and an in-memory session service.
+func inProcessEstimator(cfg config.AgentsConfig) (eval.Estimator, error) {
+	nutritionAgent, err := agents.MacroEstimator(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	sessionService := session.InMemoryService()
+	r, err := runner.New(runner.Config{
+		AppName:        nutritionAgent.Name(),
+		Agent:          nutritionAgent,
+		SessionService: sessionService,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context, c eval.Case) (string, error) {
+		created, err := sessionService.Create(ctx, &session.CreateRequest{
+			AppName: nutritionAgent.Name(),
+			UserID:  evalUserID,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		var finalText string
+		msg := genai.NewContentFromText(c.Text, genai.RoleUser)
+		for event, err := range r.Run(ctx, evalUserID, created.Session.ID(), msg, agent.RunConfig{}) {
+			if err != nil {
+				return "", err
+			}
+			if event.Content == nil || event.Content.Role != string(genai.RoleModel) {
+				continue
+			}
+			for _, part := range event.Content.Parts {
+				if part.Text != "" {
+					finalText = part.Text
+				}
+			}
+		}
+		return finalText, nil
+	}, nil
+}
@@ -0,0 +1,67 @@
+package adk
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker stops calls to a failing server. After threshold consecutive
+// failures it opens and rejects calls for the cooldown; then it lets one call
+// through, which closes it on success or opens it again on failure.
+type CircuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+// NewCircuitBreaker creates a circuit breaker. A threshold of zero or less
+// disables it.
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Allow reports whether a call may be made now.
+func (b *CircuitBreaker) Allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	now := time.Now()
+	if now.Before(b.openUntil) {
+		return false
+	}
+	// Let this call probe the server; others wait for its outcome, or for
+	// another cooldown if it never reports one.
+	b.openUntil = now.Add(b.cooldown)
+	return true
+}
+
+// Success records a call that reached a healthy server.
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+}
+
+// Failure records a call that failed because of the server.
+func (b *CircuitBreaker) Failure() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+	}
+}
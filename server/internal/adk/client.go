@@ -2,23 +2,34 @@ package adk
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/httputil"
+	"github.com/simhozebs/mugo/internal/telemetry"
 
 	"google.golang.org/adk/server/restapi/models"
 	"google.golang.org/genai"
 )
 
 // Client is an HTTP client for communicating with the ADK REST API server.
+// GET and DELETE requests that fail with 429, a 5xx status or a connection
+// error are retried according to its retry policy. POST requests, which may
+// have run an agent or created a session before failing, are retried only on
+// 429 and 503 or when they never reached the server. A circuit breaker stops
+// requests while the server keeps failing.
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	retry      RetryPolicy
+	breaker    *CircuitBreaker
 }
 
 // RunResult contains the result of running an agent.
@@ -27,39 +38,154 @@ type RunResult struct {
 	FinalText string         // Extracted final text response
 }
 
-// NewClient creates a new ADK client with the given base URL.
-// The baseURL should include the /api path, e.g., "http://localhost:8080/api"
-func NewClient(baseURL string) *Client {
+// InvocationID returns the ID of the agent invocation that produced the
+// events, under which the agents store its token usage.
+func (r *RunResult) InvocationID() string {
+	for i := len(r.Events) - 1; i >= 0; i-- {
+		if id := r.Events[i].InvocationID; id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// NewClient creates a new ADK client for the configured server, with the
+// configured timeout, retry policy and circuit breaker.
+func NewClient(cfg config.ADKConfig) *Client {
+	return NewClientWithPolicy(cfg.ServerURL, cfg.Timeout, NewRetryPolicy(cfg),
+		NewCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown))
+}
+
+// NewClientWithPolicy creates a new ADK client with an explicit request
+// timeout, retry policy and circuit breaker.
+func NewClientWithPolicy(baseURL string, timeout time.Duration, retry RetryPolicy, breaker *CircuitBreaker) *Client {
 	return &Client{
 		baseURL: strings.TrimSuffix(baseURL, "/"),
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: timeout,
+			// Carries the trace context over to the ADK server.
+			Transport: telemetry.Transport(http.DefaultTransport),
 		},
+		retry:   retry,
+		breaker: breaker,
 	}
 }
 
 // sessionURL builds the session endpoint URL.
 func (c *Client) sessionURL(appName, userID, sessionID string) string {
-	return fmt.Sprintf("%s/apps/%s/users/%s/sessions/%s", c.baseURL, appName, userID, sessionID)
+	return fmt.Sprintf("%s/%s", c.sessionsURL(appName, userID), sessionID)
+}
+
+func (c *Client) sessionsURL(appName, userID string) string {
+	return fmt.Sprintf("%s/apps/%s/users/%s/sessions", c.baseURL, appName, userID)
+}
+
+// doRequest executes an HTTP request with retries and returns the response if
+// its status is one of the accepted ones. Any other status is returned as an
+// *HTTPError, whose Err is notFound for a 404.
+func (c *Client) doRequest(ctx context.Context, method, url string, body any, notFound error, accepted ...int) (*http.Response, error) {
+	attempts := max(c.retry.MaxAttempts, 1)
+	// Repeating a POST that the server may have acted on would run the agent
+	// again, duplicating session events and LLM spend.
+	idempotent := method == http.MethodGet || method == http.MethodDelete
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if !c.breaker.Allow() {
+			if lastErr != nil {
+				return nil, fmt.Errorf("%w: %v", ErrCircuitOpen, lastErr)
+			}
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := httputil.DoRequest(ctx, c.httpClient, method, url, body)
+		if err != nil {
+			if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, err
+			}
+			if isTimeout(err) {
+				c.breaker.Failure()
+				return nil, fmt.Errorf("%w: %v", ErrUpstreamTimeout, err)
+			}
+			c.breaker.Failure()
+			if !idempotent && !notSent(err) {
+				return nil, err
+			}
+			lastErr = err
+		} else if slices.Contains(accepted, resp.StatusCode) {
+			c.breaker.Success()
+			return resp, nil
+		} else {
+			httpErr := newHTTPError(resp, notFound)
+			resp.Body.Close()
+			if !retryableStatus(resp.StatusCode) || errors.Is(httpErr, ErrAgentNotFound) {
+				c.breaker.Success()
+				return nil, httpErr
+			}
+			if resp.StatusCode != http.StatusTooManyRequests {
+				c.breaker.Failure()
+			}
+			if errors.Is(httpErr, ErrUpstreamTimeout) {
+				return nil, httpErr
+			}
+			// 429 and 503 mean the server turned the request away unprocessed.
+			if !idempotent && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+				return nil, httpErr
+			}
+			lastErr = httpErr
+		}
+
+		if attempt >= attempts {
+			return nil, lastErr
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+		case <-time.After(c.retry.backoff(attempt, resp)):
+		}
+	}
+}
+
+// newHTTPError reads the body of an unexpected response into an HTTPError.
+func newHTTPError(resp *http.Response, notFound error) *HTTPError {
+	body, _ := io.ReadAll(resp.Body)
+	httpErr := &HTTPError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(body))}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		httpErr.Err = notFound
+	case resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode == http.StatusGatewayTimeout:
+		httpErr.Err = ErrUpstreamTimeout
+	case resp.StatusCode == http.StatusInternalServerError && strings.HasPrefix(httpErr.Body, "load agent:"):
+		// The ADK server reports an unknown app name as a 500 from its agent loader.
+		httpErr.Err = ErrAgentNotFound
+	}
+	return httpErr
+}
+
+// notSent reports whether a request failed before it reached the server, so
+// retrying it cannot repeat its effects.
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
 }
 
-// doRequest executes an HTTP request and returns the response.
-func (c *Client) doRequest(ctx context.Context, method, url string, body any) (*http.Response, error) {
-	return httputil.DoRequest(ctx, c.httpClient, method, url, body)
+// isTimeout reports whether a request failed because it took too long.
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
 
 // ListApps returns a list of available agent app names.
 func (c *Client) ListApps(ctx context.Context) ([]string, error) {
-	resp, err := c.doRequest(ctx, http.MethodGet, c.baseURL+"/list-apps", nil)
+	resp, err := c.doRequest(ctx, http.MethodGet, c.baseURL+"/list-apps", nil, nil, http.StatusOK)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if err := httputil.CheckStatus(resp, http.StatusOK); err != nil {
-		return nil, err
-	}
-
 	var apps []string
 	if err := httputil.DecodeJSON(resp, &apps); err != nil {
 		return nil, err
@@ -71,16 +197,12 @@ func (c *Client) ListApps(ctx context.Context) ([]string, error) {
 func (c *Client) CreateSession(ctx context.Context, appName, userID, sessionID string, state map[string]any) (*models.Session, error) {
 	reqBody := models.CreateSessionRequest{State: state}
 
-	resp, err := c.doRequest(ctx, http.MethodPost, c.sessionURL(appName, userID, sessionID), reqBody)
+	resp, err := c.doRequest(ctx, http.MethodPost, c.sessionURL(appName, userID, sessionID), reqBody, nil, http.StatusOK, http.StatusCreated)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if err := httputil.CheckStatus(resp, http.StatusOK, http.StatusCreated); err != nil {
-		return nil, err
-	}
-
 	var session models.Session
 	if err := httputil.DecodeJSON(resp, &session); err != nil {
 		return nil, err
@@ -91,60 +213,60 @@ func (c *Client) CreateSession(ctx context.Context, appName, userID, sessionID s
 // GetSession retrieves an existing session.
 // Returns nil, nil if the session is not found.
 func (c *Client) GetSession(ctx context.Context, appName, userID, sessionID string) (*models.Session, error) {
-	resp, err := c.doRequest(ctx, http.MethodGet, c.sessionURL(appName, userID, sessionID), nil)
+	resp, err := c.doRequest(ctx, http.MethodGet, c.sessionURL(appName, userID, sessionID), nil, ErrSessionNotFound, http.StatusOK)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, nil
+	var session models.Session
+	if err := httputil.DecodeJSON(resp, &session); err != nil {
+		return nil, err
 	}
+	return &session, nil
+}
 
-	if err := httputil.CheckStatus(resp, http.StatusOK); err != nil {
+// ListSessions returns the sessions of the user in the given app.
+func (c *Client) ListSessions(ctx context.Context, appName, userID string) ([]models.Session, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, c.sessionsURL(appName, userID), nil, nil, http.StatusOK)
+	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	var session models.Session
-	if err := httputil.DecodeJSON(resp, &session); err != nil {
+	var sessions []models.Session
+	if err := httputil.DecodeJSON(resp, &sessions); err != nil {
 		return nil, err
 	}
-	return &session, nil
+	return sessions, nil
 }
 
 // DeleteSession deletes an existing session.
 func (c *Client) DeleteSession(ctx context.Context, appName, userID, sessionID string) error {
-	resp, err := c.doRequest(ctx, http.MethodDelete, c.sessionURL(appName, userID, sessionID), nil)
+	resp, err := c.doRequest(ctx, http.MethodDelete, c.sessionURL(appName, userID, sessionID), nil, ErrSessionNotFound, http.StatusOK, http.StatusNoContent)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-
-	return httputil.CheckStatus(resp, http.StatusOK, http.StatusNoContent)
+	return resp.Body.Close()
 }
 
 // Run executes an agent with the given request.
 // It does NOT auto-create sessions. Use RunWithAutoSession for that.
+// It returns an error matching ErrSessionNotFound if the session does not exist.
 func (c *Client) Run(ctx context.Context, runReq models.RunAgentRequest) (*RunResult, error) {
-	resp, err := c.doRequest(ctx, http.MethodPost, c.baseURL+"/run", runReq)
+	// The ADK server answers 404 on /run only when the session does not exist.
+	resp, err := c.doRequest(ctx, http.MethodPost, c.baseURL+"/run", runReq, ErrSessionNotFound, http.StatusOK)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Read body first since we need it for both error and success cases
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-	}
-
 	var events []models.Event
-	if err := json.Unmarshal(body, &events); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := httputil.DecodeJSON(resp, &events); err != nil {
+		return nil, err
 	}
 
 	return &RunResult{
@@ -154,22 +276,21 @@ func (c *Client) Run(ctx context.Context, runReq models.RunAgentRequest) (*RunRe
 }
 
 // RunWithAutoSession executes an agent, automatically creating a session if it doesn't exist.
+// The state is the initial state of a newly created session and is sent as
+// the state delta of runs in an existing session; it may be nil.
 // This is the recommended method for most use cases.
-func (c *Client) RunWithAutoSession(ctx context.Context, runReq models.RunAgentRequest) (*RunResult, error) {
-	result, err := c.Run(ctx, runReq)
-	if err == nil {
-		return result, nil
+func (c *Client) RunWithAutoSession(ctx context.Context, runReq models.RunAgentRequest, state map[string]any) (*RunResult, error) {
+	withState := runReq
+	if len(state) > 0 {
+		withState.StateDelta = &state
 	}
-
-	// Check if the error is due to session not found
-	errStr := err.Error()
-	if !strings.Contains(errStr, "session") || !strings.Contains(errStr, "not found") {
-		fmt.Printf("Run failed with non-session error: %v\n", err)
-		return nil, err
+	result, err := c.Run(ctx, withState)
+	if !errors.Is(err, ErrSessionNotFound) {
+		return result, err
 	}
 
 	// Create the session and retry
-	if _, createErr := c.CreateSession(ctx, runReq.AppName, runReq.UserId, runReq.SessionId, nil); createErr != nil {
+	if _, createErr := c.CreateSession(ctx, runReq.AppName, runReq.UserId, runReq.SessionId, state); createErr != nil {
 		return nil, fmt.Errorf("failed to create session: %w (original error: %v)", createErr, err)
 	}
 
@@ -0,0 +1,280 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/server/restapi/models"
+)
+
+// testPolicy retries quickly, so tests do not wait on backoff.
+var testPolicy = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Millisecond,
+	MaxBackoff:     5 * time.Millisecond,
+	Multiplier:     2,
+}
+
+// newTestClient starts a stand-in ADK server with handler and returns a
+// client for it and the number of requests the server received.
+func newTestClient(t *testing.T, policy RetryPolicy, breaker *CircuitBreaker, handler http.HandlerFunc) (*Client, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	if breaker == nil {
+		breaker = NewCircuitBreaker(0, 0)
+	}
+	return NewClientWithPolicy(server.URL, time.Second, policy, breaker), &calls
+}
+
+func runRequest() models.RunAgentRequest {
+	return models.RunAgentRequest{AppName: "nutrition", UserId: "u1", SessionId: "s1"}
+}
+
+func TestClientErrorMapping(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{"missing session", http.StatusNotFound, "session not found", ErrSessionNotFound},
+		{"unknown agent", http.StatusInternalServerError, "load agent: agent \"nutrition\" not found", ErrAgentNotFound},
+		{"gateway timeout", http.StatusGatewayTimeout, "timeout", ErrUpstreamTimeout},
+		{"request timeout", http.StatusRequestTimeout, "timeout", ErrUpstreamTimeout},
+		{"bad request", http.StatusBadRequest, "invalid request", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newTestClient(t, RetryPolicy{MaxAttempts: 1}, nil, func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, tt.body, tt.status)
+			})
+
+			_, err := client.Run(context.Background(), runRequest())
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Run error = %v, want %v", err, tt.wantErr)
+			}
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("Run error = %v, want *HTTPError", err)
+			}
+			if httpErr.StatusCode != tt.status || httpErr.Body != tt.body {
+				t.Errorf("HTTPError = %d %q, want %d %q", httpErr.StatusCode, httpErr.Body, tt.status, tt.body)
+			}
+			if tt.wantErr == nil && httpErr.Err != nil {
+				t.Errorf("HTTPError.Err = %v, want nil", httpErr.Err)
+			}
+		})
+	}
+}
+
+func TestClientGetSessionNotFound(t *testing.T) {
+	client, _ := newTestClient(t, testPolicy, nil, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	session, err := client.GetSession(context.Background(), "nutrition", "u1", "s1")
+	if err != nil || session != nil {
+		t.Fatalf("GetSession = %v, %v; want nil, nil", session, err)
+	}
+}
+
+func TestClientRunWithAutoSessionSendsState(t *testing.T) {
+	var got models.RunAgentRequest
+	client, _ := newTestClient(t, testPolicy, nil, func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode([]models.Event{})
+	})
+
+	state := map[string]any{"user:allergens": []any{"peanuts"}}
+	if _, err := client.RunWithAutoSession(context.Background(), runRequest(), state); err != nil {
+		t.Fatal(err)
+	}
+	if got.StateDelta == nil || !reflect.DeepEqual(*got.StateDelta, state) {
+		t.Errorf("stateDelta = %v, want %v", got.StateDelta, state)
+	}
+}
+
+func TestClientListSessions(t *testing.T) {
+	client, _ := newTestClient(t, testPolicy, nil, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/apps/nutrition/users/u1/sessions" {
+			t.Errorf("request = %s %s", r.Method, r.URL.Path)
+		}
+		json.NewEncoder(w).Encode([]models.Session{{ID: "s1"}, {ID: "s2"}})
+	})
+
+	sessions, err := client.ListSessions(context.Background(), "nutrition", "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "s1" || sessions[1].ID != "s2" {
+		t.Errorf("ListSessions = %+v, want s1, s2", sessions)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClientWithPolicy(server.URL, 20*time.Millisecond, testPolicy, NewCircuitBreaker(0, 0))
+	_, err := client.ListApps(context.Background())
+	if !errors.Is(err, ErrUpstreamTimeout) {
+		t.Fatalf("ListApps error = %v, want ErrUpstreamTimeout", err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		run       bool
+		wantCalls int32
+		wantOK    bool
+	}{
+		{"GET retried on 500", http.StatusInternalServerError, false, 3, true},
+		{"GET retried on 429", http.StatusTooManyRequests, false, 3, true},
+		{"run retried on 503", http.StatusServiceUnavailable, true, 3, true},
+		{"run retried on 429", http.StatusTooManyRequests, true, 3, true},
+		{"run not retried on 500", http.StatusInternalServerError, true, 1, false},
+		{"run not retried on 502", http.StatusBadGateway, true, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failures atomic.Int32
+			client, calls := newTestClient(t, testPolicy, nil, func(w http.ResponseWriter, r *http.Request) {
+				if failures.Add(1) <= 2 {
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "unavailable", tt.status)
+					return
+				}
+				if tt.run {
+					json.NewEncoder(w).Encode([]models.Event{})
+				} else {
+					json.NewEncoder(w).Encode([]string{"nutrition"})
+				}
+			})
+
+			var err error
+			if tt.run {
+				_, err = client.Run(context.Background(), runRequest())
+			} else {
+				_, err = client.ListApps(context.Background())
+			}
+			if (err == nil) != tt.wantOK {
+				t.Fatalf("error = %v, want success %v", err, tt.wantOK)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestNotSentDialError(t *testing.T) {
+	// Nothing listens on the address of a closed server, so the run is never sent.
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := NewClientWithPolicy(url, time.Second, testPolicy, NewCircuitBreaker(0, 0))
+	_, err := client.Run(context.Background(), runRequest())
+	if err == nil || !notSent(err) {
+		t.Fatalf("Run error = %v, want a dial error", err)
+	}
+}
+
+func TestClientGivesUpAfterMaxAttempts(t *testing.T) {
+	client, calls := newTestClient(t, testPolicy, nil, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	})
+
+	_, err := client.ListApps(context.Background())
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("ListApps error = %v, want a 503 HTTPError", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+	tests := []struct {
+		name  string
+		retry int
+		resp  *http.Response
+		want  time.Duration
+	}{
+		{"first retry", 1, nil, 100 * time.Millisecond},
+		{"grows", 3, nil, 400 * time.Millisecond},
+		{"capped", 6, nil, time.Second},
+		{"longer Retry-After wins", 1, retryAfter("1"), time.Second},
+		{"Retry-After capped", 1, retryAfter("30"), time.Second},
+		{"shorter Retry-After ignored", 3, retryAfter("0"), 400 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.backoff(tt.retry, tt.resp); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.retry, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	var healthy atomic.Bool
+	breaker := NewCircuitBreaker(2, 50*time.Millisecond)
+	client, calls := newTestClient(t, RetryPolicy{MaxAttempts: 1}, breaker, func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			http.Error(w, "down", http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode([]string{"nutrition"})
+	})
+	ctx := context.Background()
+
+	for range 2 {
+		if _, err := client.ListApps(ctx); err == nil {
+			t.Fatal("ListApps succeeded against a failing server")
+		}
+	}
+	if _, err := client.ListApps(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("ListApps error = %v, want ErrCircuitOpen", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("server got %d requests while the circuit was open, want 2", got)
+	}
+
+	healthy.Store(true)
+	time.Sleep(60 * time.Millisecond)
+	if _, err := client.ListApps(ctx); err != nil {
+		t.Fatalf("ListApps after cooldown: %v", err)
+	}
+	if _, err := client.ListApps(ctx); err != nil {
+		t.Fatalf("ListApps with the circuit closed: %v", err)
+	}
+	if got := calls.Load(); got != 4 {
+		t.Errorf("server got %d requests, want 4", got)
+	}
+}
@@ -0,0 +1,210 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/server/restapi/models"
+	"google.golang.org/adk/server/restapi/services"
+	"google.golang.org/adk/session"
+)
+
+// EmbeddedRunner runs agents in-process with the ADK runner, so the API
+// server does not need a separate ADK server.
+type EmbeddedRunner struct {
+	agentLoader    services.AgentLoader
+	sessionService session.Service
+}
+
+// NewEmbeddedRunner creates a runner for the agents of the loader, storing
+// sessions in the given session service. The service must report a missing
+// session with an error matching ErrSessionNotFound, as
+// PostgresSessionService and NewInMemorySessionService do.
+func NewEmbeddedRunner(agentLoader services.AgentLoader, sessionService session.Service) *EmbeddedRunner {
+	return &EmbeddedRunner{
+		agentLoader:    agentLoader,
+		sessionService: sessionService,
+	}
+}
+
+// ListApps returns a list of available agent app names.
+func (r *EmbeddedRunner) ListApps(ctx context.Context) ([]string, error) {
+	return r.agentLoader.ListAgents(), nil
+}
+
+// CreateSession creates a new session for the given app, user, and session ID.
+func (r *EmbeddedRunner) CreateSession(ctx context.Context, appName, userID, sessionID string, state map[string]any) (*models.Session, error) {
+	created, err := r.sessionService.Create(ctx, &session.CreateRequest{
+		AppName:   appName,
+		UserID:    userID,
+		SessionID: sessionID,
+		State:     state,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	s, err := models.FromSession(created.Session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert session: %w", err)
+	}
+	return &s, nil
+}
+
+// GetSession retrieves an existing session.
+// Returns nil, nil if the session is not found. Other failures of the session
+// service, such as an unreachable database, are returned.
+func (r *EmbeddedRunner) GetSession(ctx context.Context, appName, userID, sessionID string) (*models.Session, error) {
+	got, err := r.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   appName,
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	s, err := models.FromSession(got.Session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert session: %w", err)
+	}
+	return &s, nil
+}
+
+// ListSessions returns the sessions of the user in the given app.
+func (r *EmbeddedRunner) ListSessions(ctx context.Context, appName, userID string) ([]models.Session, error) {
+	listed, err := r.sessionService.List(ctx, &session.ListRequest{
+		AppName: appName,
+		UserID:  userID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	sessions := make([]models.Session, 0, len(listed.Sessions))
+	for _, listedSession := range listed.Sessions {
+		s, err := models.FromSession(listedSession)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// DeleteSession deletes an existing session.
+func (r *EmbeddedRunner) DeleteSession(ctx context.Context, appName, userID, sessionID string) error {
+	err := r.sessionService.Delete(ctx, &session.DeleteRequest{
+		AppName:   appName,
+		UserID:    userID,
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// Run executes an agent with the given request.
+// It does NOT auto-create sessions. Use RunWithAutoSession for that.
+// It returns an error matching ErrSessionNotFound if the session does not exist.
+func (r *EmbeddedRunner) Run(ctx context.Context, runReq models.RunAgentRequest) (*RunResult, error) {
+	existing, err := r.GetSession(ctx, runReq.AppName, runReq.UserId, runReq.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, fmt.Errorf("session %s: %w", runReq.SessionId, ErrSessionNotFound)
+	}
+	return r.run(ctx, runReq)
+}
+
+// RunWithAutoSession executes an agent, automatically creating a session if it doesn't exist.
+// The state is the initial state of a newly created session and is applied to
+// an existing session before the run; it may be nil.
+func (r *EmbeddedRunner) RunWithAutoSession(ctx context.Context, runReq models.RunAgentRequest, state map[string]any) (*RunResult, error) {
+	existing, err := r.GetSession(ctx, runReq.AppName, runReq.UserId, runReq.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		if _, err := r.CreateSession(ctx, runReq.AppName, runReq.UserId, runReq.SessionId, state); err != nil {
+			return nil, err
+		}
+	} else if len(state) > 0 {
+		runReq.StateDelta = &state
+	}
+	return r.run(ctx, runReq)
+}
+
+// run executes the agent in a session that is known to exist.
+func (r *EmbeddedRunner) run(ctx context.Context, runReq models.RunAgentRequest) (*RunResult, error) {
+	appAgent, err := r.agentLoader.LoadAgent(runReq.AppName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrAgentNotFound, err)
+	}
+	if err := ApplyStateDelta(ctx, r.sessionService, runReq); err != nil {
+		return nil, err
+	}
+
+	agentRunner, err := runner.New(runner.Config{
+		AppName:        runReq.AppName,
+		Agent:          appAgent,
+		SessionService: r.sessionService,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runner: %w", err)
+	}
+
+	var events []models.Event
+	msg := runReq.NewMessage
+	for event, err := range agentRunner.Run(ctx, runReq.UserId, runReq.SessionId, &msg, agent.RunConfig{}) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to run agent: %w", err)
+		}
+		events = append(events, models.FromSessionEvent(*event))
+	}
+
+	return &RunResult{
+		Events:    events,
+		FinalText: extractFinalText(events),
+	}, nil
+}
+
+// ApplyStateDelta records the state delta of a run request as an event of its
+// session, before the agent runs. ADK's REST server ignores the state delta of
+// /run, so the ADK server calls this for every run as well. Nothing is recorded
+// if the request has no state delta or the session does not exist.
+func ApplyStateDelta(ctx context.Context, sessionService session.Service, runReq models.RunAgentRequest) error {
+	if runReq.StateDelta == nil || len(*runReq.StateDelta) == 0 {
+		return nil
+	}
+
+	got, err := sessionService.Get(ctx, &session.GetRequest{
+		AppName:   runReq.AppName,
+		UserID:    runReq.UserId,
+		SessionID: runReq.SessionId,
+	})
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	// The event has no content, so agents do not see it in the conversation.
+	event := session.NewEvent("")
+	event.Author = "user"
+	maps.Copy(event.Actions.StateDelta, *runReq.StateDelta)
+	if err := sessionService.AppendEvent(ctx, got.Session, event); err != nil {
+		return fmt.Errorf("failed to apply state delta: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"testing"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/server/restapi/services"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// failingSessionService fails every Get with err.
+type failingSessionService struct {
+	session.Service
+	err error
+}
+
+func (s failingSessionService) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	return nil, s.err
+}
+
+func TestEmbeddedGetSession(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("in-memory not found", func(t *testing.T) {
+		r := NewEmbeddedRunner(nil, NewInMemorySessionService())
+		got, err := r.GetSession(ctx, "nutrition", "u1", "s1")
+		if err != nil || got != nil {
+			t.Fatalf("GetSession = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("ErrSessionNotFound", func(t *testing.T) {
+		r := NewEmbeddedRunner(nil, failingSessionService{err: ErrSessionNotFound})
+		got, err := r.GetSession(ctx, "nutrition", "u1", "s1")
+		if err != nil || got != nil {
+			t.Fatalf("GetSession = %v, %v; want nil, nil", got, err)
+		}
+	})
+
+	t.Run("service failure", func(t *testing.T) {
+		dbErr := errors.New("connection refused")
+		r := NewEmbeddedRunner(nil, failingSessionService{err: dbErr})
+		if _, err := r.GetSession(ctx, "nutrition", "u1", "s1"); !errors.Is(err, dbErr) {
+			t.Fatalf("GetSession error = %v, want %v", err, dbErr)
+		}
+		if _, err := r.RunWithAutoSession(ctx, runRequest(), nil); !errors.Is(err, dbErr) {
+			t.Fatalf("RunWithAutoSession error = %v, want %v", err, dbErr)
+		}
+	})
+}
+
+// allergensAgent answers with the allergens in the session state.
+func allergensAgent(t *testing.T) agent.Agent {
+	t.Helper()
+	a, err := agent.New(agent.Config{
+		Name: "nutrition",
+		Run: func(ctx agent.InvocationContext) iter.Seq2[*session.Event, error] {
+			return func(yield func(*session.Event, error) bool) {
+				allergens, err := ctx.Session().State().Get("user:allergens")
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				event := session.NewEvent(ctx.InvocationID())
+				event.LLMResponse = model.LLMResponse{Content: genai.NewContentFromText(fmt.Sprint(allergens), genai.RoleModel)}
+				yield(event, nil)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestEmbeddedRunWithAutoSessionAppliesState(t *testing.T) {
+	ctx := context.Background()
+	r := NewEmbeddedRunner(services.NewSingleAgentLoader(allergensAgent(t)), NewInMemorySessionService())
+
+	runs := []struct {
+		allergens string
+		want      string
+	}{
+		{"peanuts", "peanuts"}, // creates the session
+		{"shellfish", "shellfish"},
+	}
+	for _, run := range runs {
+		result, err := r.RunWithAutoSession(ctx, runRequest(), map[string]any{"user:allergens": run.allergens})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.FinalText != run.want {
+			t.Errorf("FinalText = %q, want %q", result.FinalText, run.want)
+		}
+	}
+
+	// Without state, the last profile is kept.
+	result, err := r.RunWithAutoSession(ctx, runRequest(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.FinalText != "shellfish" {
+		t.Errorf("FinalText = %q, want %q", result.FinalText, "shellfish")
+	}
+}
+
+func TestApplyStateDeltaMissingSession(t *testing.T) {
+	runReq := runRequest()
+	runReq.StateDelta = &map[string]any{"user:allergens": "peanuts"}
+	if err := ApplyStateDelta(context.Background(), NewInMemorySessionService(), runReq); err != nil {
+		t.Fatalf("ApplyStateDelta error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,36 @@
+package adk
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrSessionNotFound means the session does not exist.
+	ErrSessionNotFound = errors.New("session not found")
+	// ErrAgentNotFound means no agent is loaded under the app name.
+	ErrAgentNotFound = errors.New("agent not found")
+	// ErrUpstreamTimeout means the ADK server did not answer in time.
+	ErrUpstreamTimeout = errors.New("agent server timed out")
+	// ErrCircuitOpen means requests are not sent because the ADK server kept failing.
+	ErrCircuitOpen = errors.New("agent server circuit open")
+)
+
+// HTTPError is an unexpected status from the ADK server. When the status has
+// a known meaning, Err is the matching sentinel error, e.g. ErrSessionNotFound.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+	Err        error
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%v (status %d): %s", e.Err, e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("unexpected status %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
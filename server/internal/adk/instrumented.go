@@ -0,0 +1,51 @@
+package adk
+
+import (
+	"context"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/adk/server/restapi/models"
+)
+
+// Instrument wraps an AgentRunner so every agent run gets a span and is
+// counted in the agent latency metric.
+func Instrument(runner AgentRunner) AgentRunner {
+	return &instrumentedRunner{AgentRunner: runner}
+}
+
+type instrumentedRunner struct {
+	AgentRunner
+}
+
+func (r *instrumentedRunner) Run(ctx context.Context, runReq models.RunAgentRequest) (*RunResult, error) {
+	return observeRun(ctx, runReq, func(ctx context.Context) (*RunResult, error) {
+		return r.AgentRunner.Run(ctx, runReq)
+	})
+}
+
+func (r *instrumentedRunner) RunWithAutoSession(ctx context.Context, runReq models.RunAgentRequest, state map[string]any) (*RunResult, error) {
+	return observeRun(ctx, runReq, func(ctx context.Context) (*RunResult, error) {
+		return r.AgentRunner.RunWithAutoSession(ctx, runReq, state)
+	})
+}
+
+func observeRun(ctx context.Context, runReq models.RunAgentRequest, run func(ctx context.Context) (*RunResult, error)) (*RunResult, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "agent.run "+runReq.AppName, trace.WithAttributes(
+		attribute.String("agent.app", runReq.AppName),
+		attribute.String("agent.session_id", runReq.SessionId),
+	))
+	defer span.End()
+
+	start := time.Now()
+	result, err := run(ctx)
+	telemetry.RecordAgentRun(ctx, runReq.AppName, time.Since(start), err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return result, err
+}
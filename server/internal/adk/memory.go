@@ -0,0 +1,31 @@
+package adk
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/adk/session"
+)
+
+// memorySessionService is ADK's in-memory session service with a missing
+// session reported as ErrSessionNotFound, like PostgresSessionService does.
+type memorySessionService struct {
+	session.Service
+}
+
+// NewInMemorySessionService creates a session service that keeps sessions in
+// memory, for running without a database.
+func NewInMemorySessionService() session.Service {
+	return memorySessionService{Service: session.InMemoryService()}
+}
+
+// Get returns a session with its events, filtered as requested.
+func (s memorySessionService) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	got, err := s.Service.Get(ctx, req)
+	// ADK's in-memory service has no error to match a missing session
+	// against, only this message.
+	if err != nil && err.Error() == fmt.Sprintf("session %s not found", req.SessionID) {
+		return nil, fmt.Errorf("session %s: %w", req.SessionID, ErrSessionNotFound)
+	}
+	return got, err
+}
@@ -0,0 +1,27 @@
+package adk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"google.golang.org/adk/session"
+)
+
+func TestInMemorySessionServiceGet(t *testing.T) {
+	ctx := context.Background()
+	s := NewInMemorySessionService()
+	if _, err := s.Create(ctx, &session.CreateRequest{AppName: "nutrition", UserID: "u1", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Get(ctx, &session.GetRequest{AppName: "nutrition", UserID: "u1", SessionID: "s1"}); err != nil {
+		t.Errorf("Get of an existing session: %v", err)
+	}
+	if _, err := s.Get(ctx, &session.GetRequest{AppName: "nutrition", UserID: "u1", SessionID: "s2"}); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Get of a missing session = %v, want ErrSessionNotFound", err)
+	}
+	if _, err := s.Get(ctx, &session.GetRequest{AppName: "nutrition", UserID: "u1"}); err == nil || errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Get without a session ID = %v, want a request error", err)
+	}
+}
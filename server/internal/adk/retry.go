@@ -0,0 +1,61 @@
+package adk
+
+import (
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/config"
+)
+
+// RetryPolicy controls how Client retries requests that fail with 429, a 5xx
+// status or a connection error. Client narrows which POST requests it retries.
+type RetryPolicy struct {
+	MaxAttempts    int           // total attempts, including the first one
+	InitialBackoff time.Duration // delay before the first retry
+	MaxBackoff     time.Duration // upper bound of any delay
+	Multiplier     float64       // growth of the delay per retry
+	Jitter         float64       // fraction of the delay randomized, 0 to 1
+}
+
+// NewRetryPolicy returns the configured retry policy.
+func NewRetryPolicy(cfg config.ADKConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    cfg.RetryMaxAttempts,
+		InitialBackoff: cfg.RetryInitialBackoff,
+		MaxBackoff:     cfg.RetryMaxBackoff,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// backoff returns the delay before the given retry, counting from 1.
+// A Retry-After header on the response takes precedence when it is longer.
+func (p RetryPolicy) backoff(retry int, resp *http.Response) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			if retryAfter := time.Duration(seconds) * time.Second; retryAfter > time.Duration(delay) {
+				delay = float64(retryAfter)
+				if p.MaxBackoff > 0 && delay > float64(p.MaxBackoff) {
+					delay = float64(p.MaxBackoff)
+				}
+			}
+		}
+	}
+	return time.Duration(delay)
+}
+
+// retryableStatus reports whether a request that got this status may succeed if retried.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
@@ -0,0 +1,32 @@
+package adk
+
+import (
+	"context"
+
+	"google.golang.org/adk/server/restapi/models"
+)
+
+// AgentRunner runs agents and manages their sessions. Client reaches a
+// separate ADK server over HTTP; EmbeddedRunner runs the agents in-process.
+type AgentRunner interface {
+	// ListApps returns a list of available agent app names.
+	ListApps(ctx context.Context) ([]string, error)
+	// CreateSession creates a new session for the given app, user, and session ID.
+	CreateSession(ctx context.Context, appName, userID, sessionID string, state map[string]any) (*models.Session, error)
+	// GetSession retrieves an existing session, or returns nil, nil if it is not found.
+	GetSession(ctx context.Context, appName, userID, sessionID string) (*models.Session, error)
+	// ListSessions returns the sessions of the user in the given app.
+	ListSessions(ctx context.Context, appName, userID string) ([]models.Session, error)
+	// DeleteSession deletes an existing session.
+	DeleteSession(ctx context.Context, appName, userID, sessionID string) error
+	// Run executes an agent in an existing session.
+	Run(ctx context.Context, runReq models.RunAgentRequest) (*RunResult, error)
+	// RunWithAutoSession executes an agent, creating the session with the given
+	// initial state if it doesn't exist, and applying the state to it otherwise.
+	RunWithAutoSession(ctx context.Context, runReq models.RunAgentRequest, state map[string]any) (*RunResult, error)
+}
+
+var (
+	_ AgentRunner = (*Client)(nil)
+	_ AgentRunner = (*EmbeddedRunner)(nil)
+)
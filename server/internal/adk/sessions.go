@@ -0,0 +1,394 @@
+package adk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"maps"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+	"google.golang.org/adk/session"
+)
+
+// PostgresSessionService is a session.Service that keeps ADK sessions, their
+// events and state in Postgres, so conversations survive restarts.
+//
+// Like the ADK in-memory service, "app:" and "user:" state keys are stored
+// once per app and per user and merged into every session state, and "temp:"
+// keys are never stored.
+type PostgresSessionService struct {
+	database *db.Database
+}
+
+// NewPostgresSessionService creates a session service backed by the database.
+func NewPostgresSessionService(database *db.Database) *PostgresSessionService {
+	return &PostgresSessionService{database: database}
+}
+
+var _ session.Service = (*PostgresSessionService)(nil)
+
+// Create creates a session, generating its ID if the request has none.
+func (s *PostgresSessionService) Create(ctx context.Context, req *session.CreateRequest) (*session.CreateResponse, error) {
+	if req.AppName == "" || req.UserID == "" {
+		return nil, fmt.Errorf("app_name and user_id are required, got app_name: %q, user_id: %q", req.AppName, req.UserID)
+	}
+
+	sessionID := req.SessionID
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+	appDelta, userDelta, sessionState := splitStateDelta(req.State)
+
+	var created *pgSession
+	err := s.database.WithTx(ctx, func(ctx context.Context, txDB *db.TxDatabase) error {
+		existing, err := txDB.SessionRepository.Get(ctx, req.AppName, req.UserID, sessionID)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return fmt.Errorf("session %s already exists", sessionID)
+		}
+
+		stored, err := txDB.SessionRepository.Create(ctx, req.AppName, req.UserID, sessionID, sessionState)
+		if err != nil {
+			return err
+		}
+		appState, userState, err := mergeSharedState(ctx, txDB, req.AppName, req.UserID, appDelta, userDelta)
+		if err != nil {
+			return err
+		}
+
+		created = newPGSession(stored, mergeStates(appState, userState, stored.State), nil)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session.CreateResponse{Session: created}, nil
+}
+
+// Get returns a session with its events, filtered as requested.
+func (s *PostgresSessionService) Get(ctx context.Context, req *session.GetRequest) (*session.GetResponse, error) {
+	if req.AppName == "" || req.UserID == "" || req.SessionID == "" {
+		return nil, fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", req.AppName, req.UserID, req.SessionID)
+	}
+
+	repo := s.database.SessionRepository
+	stored, err := repo.Get(ctx, req.AppName, req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("session %s: %w", req.SessionID, ErrSessionNotFound)
+	}
+
+	storedEvents, err := repo.ListEvents(ctx, req.AppName, req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]*session.Event, 0, len(storedEvents))
+	for _, e := range storedEvents {
+		var event session.Event
+		if err := json.Unmarshal(e.Event, &event); err != nil {
+			return nil, fmt.Errorf("failed to decode event %s: %w", e.ID, err)
+		}
+		events = append(events, &event)
+	}
+	events = filterEvents(events, req.NumRecentEvents, req.After)
+
+	state, err := s.sessionState(ctx, stored)
+	if err != nil {
+		return nil, err
+	}
+	return &session.GetResponse{Session: newPGSession(stored, state, events)}, nil
+}
+
+// filterEvents returns the events at or after after, if set, and of those
+// the last numRecent, if positive. The events must be in time order.
+func filterEvents(events []*session.Event, numRecent int, after time.Time) []*session.Event {
+	if !after.IsZero() {
+		first := sort.Search(len(events), func(i int) bool {
+			return !events[i].Timestamp.Before(after)
+		})
+		events = events[first:]
+	}
+	if numRecent > 0 && len(events) > numRecent {
+		events = events[len(events)-numRecent:]
+	}
+	return events
+}
+
+// List returns the sessions of an app, without their events. An empty user ID
+// lists the sessions of every user.
+func (s *PostgresSessionService) List(ctx context.Context, req *session.ListRequest) (*session.ListResponse, error) {
+	if req.AppName == "" {
+		return nil, fmt.Errorf("app_name is required, got app_name: %q", req.AppName)
+	}
+
+	stored, err := s.database.SessionRepository.List(ctx, req.AppName, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	sessions := make([]session.Session, 0, len(stored))
+	for _, st := range stored {
+		state, err := s.sessionState(ctx, st)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, newPGSession(st, state, nil))
+	}
+	return &session.ListResponse{Sessions: sessions}, nil
+}
+
+// Delete deletes a session and its events.
+func (s *PostgresSessionService) Delete(ctx context.Context, req *session.DeleteRequest) error {
+	if req.AppName == "" || req.UserID == "" || req.SessionID == "" {
+		return fmt.Errorf("app_name, user_id, session_id are required, got app_name: %q, user_id: %q, session_id: %q", req.AppName, req.UserID, req.SessionID)
+	}
+	return s.database.SessionRepository.Delete(ctx, req.AppName, req.UserID, req.SessionID)
+}
+
+// AppendEvent stores an event and applies its state delta, both to the stored
+// state and to the given session.
+func (s *PostgresSessionService) AppendEvent(ctx context.Context, cur session.Session, event *session.Event) error {
+	if cur == nil {
+		return fmt.Errorf("session is nil")
+	}
+	if event == nil {
+		return fmt.Errorf("event is nil")
+	}
+	if event.Partial {
+		return nil
+	}
+
+	sess, ok := cur.(*pgSession)
+	if !ok {
+		return fmt.Errorf("unexpected session type %T", cur)
+	}
+
+	// Temporary state only lives for the invocation.
+	for key := range event.Actions.StateDelta {
+		if strings.HasPrefix(key, session.KeyPrefixTemp) {
+			delete(event.Actions.StateDelta, key)
+		}
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	appDelta, userDelta, sessionDelta := splitStateDelta(event.Actions.StateDelta)
+
+	err = s.database.WithTx(ctx, func(ctx context.Context, txDB *db.TxDatabase) error {
+		err := txDB.SessionRepository.AppendEvent(ctx, sess.appName, sess.userID, sess.id, models.AgentEvent{
+			ID:           event.ID,
+			InvocationID: event.InvocationID,
+			Author:       event.Author,
+			Event:        data,
+			Timestamp:    event.Timestamp,
+		})
+		if err != nil {
+			return err
+		}
+		if err := txDB.SessionRepository.MergeState(ctx, sess.appName, sess.userID, sess.id, sessionDelta, event.Timestamp); err != nil {
+			return err
+		}
+		_, _, err = mergeSharedState(ctx, txDB, sess.appName, sess.userID, appDelta, userDelta)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	sess.appendEvent(event)
+	return nil
+}
+
+// sessionState returns the stored session state merged with the app and user state.
+func (s *PostgresSessionService) sessionState(ctx context.Context, stored *models.AgentSession) (map[string]any, error) {
+	repo := s.database.SessionRepository
+	appState, err := repo.GetAppState(ctx, stored.AppName)
+	if err != nil {
+		return nil, err
+	}
+	userState, err := repo.GetUserState(ctx, stored.AppName, stored.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return mergeStates(appState, userState, stored.State), nil
+}
+
+// mergeSharedState applies the app and user deltas and returns the resulting states.
+func mergeSharedState(ctx context.Context, txDB *db.TxDatabase, appName, userID string, appDelta, userDelta map[string]any) (map[string]any, map[string]any, error) {
+	repo := txDB.SessionRepository
+
+	var appState, userState map[string]any
+	var err error
+	if len(appDelta) > 0 {
+		appState, err = repo.MergeAppState(ctx, appName, appDelta)
+	} else {
+		appState, err = repo.GetAppState(ctx, appName)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(userDelta) > 0 {
+		userState, err = repo.MergeUserState(ctx, appName, userID, userDelta)
+	} else {
+		userState, err = repo.GetUserState(ctx, appName, userID)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return appState, userState, nil
+}
+
+// splitStateDelta splits a state delta into app, user and session deltas,
+// stripping the "app:" and "user:" prefixes and dropping "temp:" keys.
+func splitStateDelta(delta map[string]any) (appDelta, userDelta, sessionDelta map[string]any) {
+	appDelta = map[string]any{}
+	userDelta = map[string]any{}
+	sessionDelta = map[string]any{}
+	for key, value := range delta {
+		if appKey, ok := strings.CutPrefix(key, session.KeyPrefixApp); ok {
+			appDelta[appKey] = value
+		} else if userKey, ok := strings.CutPrefix(key, session.KeyPrefixUser); ok {
+			userDelta[userKey] = value
+		} else if !strings.HasPrefix(key, session.KeyPrefixTemp) {
+			sessionDelta[key] = value
+		}
+	}
+	return appDelta, userDelta, sessionDelta
+}
+
+// mergeStates builds the state agents see: session state plus the prefixed
+// app and user state.
+func mergeStates(appState, userState, sessionState map[string]any) map[string]any {
+	merged := make(map[string]any, len(appState)+len(userState)+len(sessionState))
+	maps.Copy(merged, sessionState)
+	for key, value := range appState {
+		merged[session.KeyPrefixApp+key] = value
+	}
+	for key, value := range userState {
+		merged[session.KeyPrefixUser+key] = value
+	}
+	return merged
+}
+
+// pgSession is the session.Session handed to the ADK runner.
+type pgSession struct {
+	appName string
+	userID  string
+	id      string
+
+	// guards all mutable fields
+	mu        sync.RWMutex
+	state     map[string]any
+	events    []*session.Event
+	updatedAt time.Time
+}
+
+func newPGSession(stored *models.AgentSession, state map[string]any, events []*session.Event) *pgSession {
+	return &pgSession{
+		appName:   stored.AppName,
+		userID:    stored.UserID,
+		id:        stored.ID,
+		state:     state,
+		events:    events,
+		updatedAt: stored.UpdatedAt,
+	}
+}
+
+func (s *pgSession) ID() string      { return s.id }
+func (s *pgSession) AppName() string { return s.appName }
+func (s *pgSession) UserID() string  { return s.userID }
+
+func (s *pgSession) State() session.State { return &pgState{session: s} }
+
+func (s *pgSession) Events() session.Events {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return pgEvents(append([]*session.Event(nil), s.events...))
+}
+
+func (s *pgSession) LastUpdateTime() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.updatedAt
+}
+
+func (s *pgSession) appendEvent(event *session.Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, value := range event.Actions.StateDelta {
+		s.state[key] = value
+	}
+	s.events = append(s.events, event)
+	s.updatedAt = event.Timestamp
+}
+
+type pgState struct {
+	session *pgSession
+}
+
+func (st *pgState) Get(key string) (any, error) {
+	st.session.mu.RLock()
+	defer st.session.mu.RUnlock()
+
+	value, ok := st.session.state[key]
+	if !ok {
+		return nil, session.ErrStateKeyNotExist
+	}
+	return value, nil
+}
+
+func (st *pgState) Set(key string, value any) error {
+	st.session.mu.Lock()
+	defer st.session.mu.Unlock()
+
+	st.session.state[key] = value
+	return nil
+}
+
+func (st *pgState) All() iter.Seq2[string, any] {
+	return func(yield func(string, any) bool) {
+		st.session.mu.RLock()
+		snapshot := maps.Clone(st.session.state)
+		st.session.mu.RUnlock()
+
+		for key, value := range snapshot {
+			if !yield(key, value) {
+				return
+			}
+		}
+	}
+}
+
+type pgEvents []*session.Event
+
+func (e pgEvents) All() iter.Seq[*session.Event] {
+	return func(yield func(*session.Event) bool) {
+		for _, event := range e {
+			if !yield(event) {
+				return
+			}
+		}
+	}
+}
+
+func (e pgEvents) Len() int { return len(e) }
+
+func (e pgEvents) At(i int) *session.Event {
+	if i >= 0 && i < len(e) {
+		return e[i]
+	}
+	return nil
+}
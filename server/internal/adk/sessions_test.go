@@ -0,0 +1,46 @@
+package adk
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/session"
+)
+
+func TestFilterEvents(t *testing.T) {
+	start := time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC)
+	var events []*session.Event
+	for i := range 5 {
+		event := session.NewEvent("inv")
+		event.ID = string(rune('a' + i))
+		event.Timestamp = start.Add(time.Duration(i) * time.Minute)
+		events = append(events, event)
+	}
+
+	tests := []struct {
+		name      string
+		numRecent int
+		after     time.Time
+		want      string
+	}{
+		{"no filter", 0, time.Time{}, "abcde"},
+		{"recent", 2, time.Time{}, "de"},
+		{"more recent than there are", 10, time.Time{}, "abcde"},
+		{"after", 0, start.Add(2 * time.Minute), "cde"},
+		{"after the last", 0, start.Add(time.Hour), ""},
+		{"recent after", 2, start.Add(time.Minute), "de"},
+		{"after leaves fewer than recent", 3, start.Add(3 * time.Minute), "de"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, event := range filterEvents(slices.Clone(events), tt.numRecent, tt.after) {
+				got += event.ID
+			}
+			if got != tt.want {
+				t.Errorf("filterEvents = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
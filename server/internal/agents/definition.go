@@ -0,0 +1,113 @@
+package agents
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"google.golang.org/genai"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed definitions/*.yaml
+var embeddedDefinitions embed.FS
+
+// Definition is the versioned configuration of an LLM agent.
+type Definition struct {
+	Name          string         `yaml:"name"`
+	Version       string         `yaml:"version"`
+	SchemaVersion string         `yaml:"schema_version"`
+	Description   string         `yaml:"description"`
+	Model         string         `yaml:"model"`
+	Temperature   *float32       `yaml:"temperature"`
+	Instruction   string         `yaml:"instruction"`
+	RawSchema     map[string]any `yaml:"output_schema"`
+
+	// OutputSchema is RawSchema decoded into a genai schema; nil if there is none.
+	OutputSchema *genai.Schema `yaml:"-"`
+}
+
+// LoadDefinition loads the definition of the named agent.
+// A <name>.yaml file in dir, when set, overrides the one embedded in the
+// binary.
+func LoadDefinition(dir, name string) (*Definition, error) {
+	data, err := readDefinition(dir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var def Definition
+	if err := yaml.Unmarshal(data, &def); err != nil {
+		return nil, fmt.Errorf("failed to parse %s definition: %w", name, err)
+	}
+	if def.Name != name {
+		return nil, fmt.Errorf("definition for %s is named %q", name, def.Name)
+	}
+	if def.Version == "" || def.Model == "" || def.Instruction == "" {
+		return nil, fmt.Errorf("%s definition needs version, model and instruction", name)
+	}
+
+	if def.RawSchema != nil {
+		if def.SchemaVersion == "" {
+			return nil, fmt.Errorf("%s definition has an output_schema but no schema_version", name)
+		}
+		// genai.Schema carries JSON tags, so round-trip through JSON.
+		schemaJSON, err := json.Marshal(def.RawSchema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s output schema: %w", name, err)
+		}
+		def.OutputSchema = &genai.Schema{}
+		if err := json.Unmarshal(schemaJSON, def.OutputSchema); err != nil {
+			return nil, fmt.Errorf("invalid %s output schema: %w", name, err)
+		}
+	}
+	return &def, nil
+}
+
+// ModelName returns the model the agent should run on. The configured model
+// name, when set, overrides the definition, e.g. to point every agent at a
+// local model.
+func (d *Definition) ModelName(cfg config.ModelConfig) string {
+	if cfg.Name != "" {
+		return cfg.Name
+	}
+	return d.Model
+}
+
+// GenerateContentConfig returns the generation settings of the definition.
+func (d *Definition) GenerateContentConfig() *genai.GenerateContentConfig {
+	if d.Temperature == nil {
+		return nil
+	}
+	return &genai.GenerateContentConfig{Temperature: d.Temperature}
+}
+
+// Stamp identifies the definition that produced an output.
+func (d *Definition) Stamp() string {
+	return d.Name + "@" + d.Version
+}
+
+func readDefinition(dir, name string) ([]byte, error) {
+	file := name + ".yaml"
+
+	if dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err == nil {
+			return data, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read %s definition override: %w", name, err)
+		}
+	}
+
+	data, err := embeddedDefinitions.ReadFile("definitions/" + file)
+	if err != nil {
+		return nil, fmt.Errorf("no definition for agent %s: %w", name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"context"
+	"iter"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/logging"
+	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/telemetry"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// instrumentedModel records the token usage the wrapped LLM reports, as
+// metrics and, when database is not nil, per agent invocation.
+type instrumentedModel struct {
+	model.LLM
+	pricing  map[string]config.ModelPrice
+	database *db.Database
+}
+
+func (m *instrumentedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for resp, err := range m.LLM.GenerateContent(ctx, req, stream) {
+			// Streamed chunks carry running totals; only the final response counts.
+			if err == nil && resp != nil && !resp.Partial && resp.UsageMetadata != nil {
+				telemetry.RecordTokenUsage(ctx, m.Name(), resp.UsageMetadata.PromptTokenCount, resp.UsageMetadata.CandidatesTokenCount)
+				m.recordUsage(ctx, resp.UsageMetadata)
+			}
+			if !yield(resp, err) {
+				return
+			}
+		}
+	}
+}
+
+// recordUsage stores the usage of a call made by an agent invocation, which
+// the API links to the meal the invocation produced.
+func (m *instrumentedModel) recordUsage(ctx context.Context, usage *genai.GenerateContentResponseUsageMetadata) {
+	// ADK calls models with the invocation context.
+	invocation, ok := ctx.(agent.InvocationContext)
+	if m.database == nil || !ok {
+		return
+	}
+
+	sess := invocation.Session()
+	err := m.database.UsageRepository.Record(context.WithoutCancel(ctx), models.LLMUsage{
+		UserID:           sess.UserID(),
+		AppName:          sess.AppName(),
+		SessionID:        sess.ID(),
+		InvocationID:     invocation.InvocationID(),
+		Model:            m.Name(),
+		PromptTokens:     int(usage.PromptTokenCount),
+		CandidatesTokens: int(usage.CandidatesTokenCount),
+		TotalTokens:      int(usage.TotalTokenCount),
+		EstimatedCostUSD: m.estimateCost(usage),
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Failed to record LLM usage", "model", m.Name(), "error", err)
+	}
+}
+
+// estimateCost returns the cost of a call from the configured price of the
+// model, or nil if it has none.
+func (m *instrumentedModel) estimateCost(usage *genai.GenerateContentResponseUsageMetadata) *float64 {
+	price, ok := m.pricing[m.Name()]
+	if !ok {
+		return nil
+	}
+	outputTokens := usage.CandidatesTokenCount + usage.ThoughtsTokenCount
+	cost := (float64(usage.PromptTokenCount)*price.InputPerMillion + float64(outputTokens)*price.OutputPerMillion) / 1e6
+	return &cost
+}
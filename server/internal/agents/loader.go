@@ -0,0 +1,44 @@
+package agents
+
+import (
+	"fmt"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/server/restapi/services"
+)
+
+// NewLoader creates every agent with the given configuration and returns a
+// loader for them. The agents store their token usage when database is not
+// nil. The coach reads meal history through the repositories, so it is only
+// loaded when database is not nil.
+func NewLoader(cfg config.AgentsConfig, database *db.Database) (services.AgentLoader, error) {
+	weatherAgent, err := Weather(cfg, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create weather agent: %w", err)
+	}
+	echoAgent, err := NewEchoAgent()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create echo agent: %w", err)
+	}
+	nutritionAgent, err := MacroEstimator(cfg, database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nutrition agent: %w", err)
+	}
+	loadedAgents := []agent.Agent{weatherAgent, echoAgent, nutritionAgent}
+
+	if database != nil {
+		coachAgent, err := NutritionCoach(cfg, database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create nutrition coach agent: %w", err)
+		}
+		loadedAgents = append(loadedAgents, coachAgent)
+	}
+
+	agentLoader, err := services.NewMultiAgentLoader(loadedAgents[0], loadedAgents[1:]...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create agent loader: %w", err)
+	}
+	return agentLoader, nil
+}
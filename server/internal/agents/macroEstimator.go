@@ -4,66 +4,29 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"os"
 
 	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
 	"github.com/simhozebs/mugo/internal/models"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
 	adkmodel "google.golang.org/adk/model"
-	"google.golang.org/adk/model/gemini"
-	"google.golang.org/genai"
 )
 
 // MacroEstimator creates the nutrition estimation agent.
-func MacroEstimator() (agent.Agent, error) {
+func MacroEstimator(cfg config.AgentsConfig, database *db.Database) (agent.Agent, error) {
 	ctx := context.Background()
-	model, err := gemini.NewModel(ctx,
-		config.ModelName,
-		&genai.ClientConfig{APIKey: os.Getenv("GOOGLE_API_KEY")})
+	def, err := LoadDefinition(cfg.DefinitionsDir, "macro_estimator")
 	if err != nil {
-		log.Fatalf("Failed to create model: %v", err)
+		return nil, err
 	}
-
-	schema := &genai.Schema{
-		Type: genai.TypeObject,
-		Properties: map[string]*genai.Schema{
-			"macros": {
-				Type: genai.TypeObject,
-				Properties: map[string]*genai.Schema{
-					"calories": {Type: genai.TypeNumber, Description: "kilocalories"},
-					"protein":  {Type: genai.TypeNumber, Description: "protein grams"},
-					"carbs":    {Type: genai.TypeNumber, Description: "carbohydrate grams"},
-					"fat":      {Type: genai.TypeNumber, Description: "fat grams"},
-				},
-				Required: []string{"calories", "protein", "carbs", "fat"},
-			},
-			"assumptions": {
-				Type: genai.TypeArray,
-				Items: &genai.Schema{
-					Type: genai.TypeObject,
-					Properties: map[string]*genai.Schema{
-						"id":            {Type: genai.TypeString, Description: "assumption id"},
-						"text":          {Type: genai.TypeString, Description: "assumption text"},
-						"category":      {Type: genai.TypeString},
-						"field":         {Type: genai.TypeString},
-						"assumed_value": {Type: genai.TypeNumber},
-						"confidence":    {Type: genai.TypeString, Description: "low|medium|high"},
-						"rationale":     {Type: genai.TypeString},
-					},
-					Required: []string{"assumed_value"},
-				},
-			},
-			"meal_type": {
-				Type:        genai.TypeString,
-				Description: "The type of meal (breakfast, lunch, dinner, or snack)",
-			},
-		},
-		Required: []string{"macros", "assumptions"},
+	model, err := NewModel(ctx, cfg.Model, def.ModelName(cfg.Model), database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model: %w", err)
 	}
 
-	// afterModel callback: strict unmarshal into NutritionPayload, assign IDs, error if schema mismatch
+	// afterModel callback: strict unmarshal into NutritionPayload, assign IDs, stamp the
+	// definition version, error if schema mismatch
 	onAfterModelAssignIDs := llmagent.AfterModelCallback(func(ctx agent.CallbackContext, resp *adkmodel.LLMResponse, respErr error) (*adkmodel.LLMResponse, error) {
 		if respErr != nil {
 			return nil, respErr
@@ -90,6 +53,11 @@ func MacroEstimator() (agent.Agent, error) {
 				payload.Assumptions[i].Unit = "g"
 			}
 		}
+		payload.Meta = &models.EstimateMeta{
+			AgentVersion:  def.Stamp(),
+			SchemaVersion: def.SchemaVersion,
+			Model:         model.Name(),
+		}
 
 		newBytes, err := json.Marshal(payload)
 		if err != nil {
@@ -100,18 +68,12 @@ func MacroEstimator() (agent.Agent, error) {
 	})
 
 	return llmagent.New(llmagent.Config{
-		Name:        "macro_estimator",
-		Model:       model,
-		Description: "Estimates nutritional value (macros) and lists assumptions based on food description.",
-		Instruction: `You are a nutritional estimation assistant.
-Your goal is to estimate the macronutrients for the food described by the user.
-You MUST provide:
-1. A short, descriptive name for the meal (e.g., "Grilled Chicken Caesar Salad", "Homemade Beef Tacos")
-2. The estimated macronutrients (calories, protein, carbs, fat)
-3. A list of assumptions you made to reach these estimates
-4. The meal type (breakfast, lunch, dinner, or snack) - use conversation context if available, otherwise infer from the food name
-`,
-		OutputSchema:        schema,
-		AfterModelCallbacks: []llmagent.AfterModelCallback{onAfterModelAssignIDs},
+		Name:                  def.Name,
+		Model:                 model,
+		Description:           def.Description,
+		Instruction:           def.Instruction,
+		GenerateContentConfig: def.GenerateContentConfig(),
+		OutputSchema:          def.OutputSchema,
+		AfterModelCallbacks:   []llmagent.AfterModelCallback{onAfterModelAssignIDs},
 	})
 }
@@ -0,0 +1,53 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/model/gemini"
+	"google.golang.org/genai"
+)
+
+// Model providers selectable with config.ModelConfig.Provider.
+const (
+	ProviderGemini = "gemini"
+	ProviderOpenAI = "openai"
+	ProviderFake   = "fake"
+)
+
+// NewModel creates the named LLM with the configured model provider.
+// Agents call this instead of constructing a provider directly, so they can
+// run against Gemini, a local OpenAI-compatible server, or a scripted fake.
+// The token usage of every call is recorded, and stored per agent invocation
+// when database is not nil.
+func NewModel(ctx context.Context, cfg config.ModelConfig, name string, database *db.Database) (model.LLM, error) {
+	llm, err := newProviderModel(ctx, cfg, name)
+	if err != nil {
+		return nil, err
+	}
+	return &instrumentedModel{LLM: llm, pricing: cfg.Pricing, database: database}, nil
+}
+
+func newProviderModel(ctx context.Context, cfg config.ModelConfig, name string) (model.LLM, error) {
+	switch provider := cfg.Provider; provider {
+	case ProviderGemini:
+		return gemini.NewModel(ctx, name, &genai.ClientConfig{APIKey: cfg.GoogleAPIKey.Value()})
+	case ProviderOpenAI:
+		return NewOpenAIModel(name, cfg.BaseURL, cfg.APIKey.Value()), nil
+	case ProviderFake:
+		scriptPath := cfg.FakeScript
+		if scriptPath == "" {
+			return NewScriptedModel(name), nil
+		}
+		turns, err := LoadScript(scriptPath)
+		if err != nil {
+			return nil, err
+		}
+		return NewScriptedModel(name, turns...), nil
+	default:
+		return nil, fmt.Errorf("unknown model provider %q", provider)
+	}
+}
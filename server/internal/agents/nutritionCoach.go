@@ -0,0 +1,40 @@
+package agents
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/tools"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+)
+
+// NutritionCoach creates the weekly coaching report agent.
+// It reads the user's history through the repositories, so it needs a database.
+func NutritionCoach(cfg config.AgentsConfig, database *db.Database) (agent.Agent, error) {
+	ctx := context.Background()
+	def, err := LoadDefinition(cfg.DefinitionsDir, "nutrition_coach")
+	if err != nil {
+		return nil, err
+	}
+	model, err := NewModel(ctx, cfg.Model, def.ModelName(cfg.Model), database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create model: %w", err)
+	}
+
+	coachTools, err := tools.CoachTools(database)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create coach tools: %w", err)
+	}
+
+	return llmagent.New(llmagent.Config{
+		Name:                  def.Name,
+		Model:                 model,
+		Description:           def.Description,
+		Instruction:           def.Instruction,
+		GenerateContentConfig: def.GenerateContentConfig(),
+		Tools:                 coachTools,
+	})
+}
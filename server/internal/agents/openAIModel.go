@@ -0,0 +1,317 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/httputil"
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// OpenAIModel is a model.LLM backed by an OpenAI-compatible chat completions
+// endpoint, such as llama.cpp's server or Ollama.
+type OpenAIModel struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewOpenAIModel creates a model for the endpoint at baseURL, e.g. "http://localhost:11434/v1".
+// The apiKey may be empty for local servers.
+func NewOpenAIModel(name, baseURL, apiKey string) *OpenAIModel {
+	return &OpenAIModel{
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		httpClient: &http.Client{
+			Timeout: 120 * time.Second,
+		},
+	}
+}
+
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	Tools          []chatTool      `json:"tools,omitempty"`
+	Temperature    *float32        `json:"temperature,omitempty"`
+	MaxTokens      int32           `json:"max_tokens,omitempty"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatMessage struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []chatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+type chatToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type chatTool struct {
+	Type     string       `json:"type"`
+	Function chatFunction `json:"function"`
+}
+
+type chatFunction struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Parameters  any    `json:"parameters,omitempty"`
+}
+
+type responseFormat struct {
+	Type       string      `json:"type"`
+	JSONSchema *jsonSchema `json:"json_schema,omitempty"`
+}
+
+type jsonSchema struct {
+	Name   string `json:"name"`
+	Schema any    `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message      chatMessage `json:"message"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int32 `json:"prompt_tokens"`
+		CompletionTokens int32 `json:"completion_tokens"`
+		TotalTokens      int32 `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Name implements model.LLM.
+func (m *OpenAIModel) Name() string {
+	return m.name
+}
+
+// GenerateContent implements model.LLM. Streaming is not supported; the
+// complete response is yielded once either way.
+func (m *OpenAIModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		resp, err := m.generate(ctx, req)
+		yield(resp, err)
+	}
+}
+
+func (m *OpenAIModel) generate(ctx context.Context, req *model.LLMRequest) (*model.LLMResponse, error) {
+	chatReq, err := m.toChatRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var headers http.Header
+	if m.apiKey != "" {
+		headers = http.Header{"Authorization": []string{"Bearer " + m.apiKey}}
+	}
+
+	resp, err := httputil.DoRequestWithHeaders(ctx, m.httpClient, http.MethodPost, m.baseURL+"/chat/completions", chatReq, headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := httputil.CheckStatus(resp, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	var chatResp chatResponse
+	if err := httputil.DecodeJSON(resp, &chatResp); err != nil {
+		return nil, err
+	}
+	if len(chatResp.Choices) == 0 {
+		return nil, fmt.Errorf("openai model: response has no choices")
+	}
+	return fromChatResponse(chatResp)
+}
+
+func (m *OpenAIModel) toChatRequest(req *model.LLMRequest) (*chatRequest, error) {
+	chatReq := &chatRequest{Model: m.name}
+	if req.Model != "" {
+		chatReq.Model = req.Model
+	}
+
+	if cfg := req.Config; cfg != nil {
+		if cfg.SystemInstruction != nil {
+			chatReq.Messages = append(chatReq.Messages, chatMessage{Role: "system", Content: joinText(cfg.SystemInstruction)})
+		}
+		chatReq.Temperature = cfg.Temperature
+		chatReq.MaxTokens = cfg.MaxOutputTokens
+
+		for _, t := range cfg.Tools {
+			for _, decl := range t.FunctionDeclarations {
+				params := decl.ParametersJsonSchema
+				if params == nil && decl.Parameters != nil {
+					params = schemaToJSON(decl.Parameters)
+				}
+				chatReq.Tools = append(chatReq.Tools, chatTool{
+					Type:     "function",
+					Function: chatFunction{Name: decl.Name, Description: decl.Description, Parameters: params},
+				})
+			}
+		}
+
+		switch {
+		case cfg.ResponseJsonSchema != nil:
+			chatReq.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: cfg.ResponseJsonSchema}}
+		case cfg.ResponseSchema != nil:
+			chatReq.ResponseFormat = &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: schemaToJSON(cfg.ResponseSchema)}}
+		case cfg.ResponseMIMEType == "application/json":
+			chatReq.ResponseFormat = &responseFormat{Type: "json_object"}
+		}
+	}
+
+	for _, content := range req.Contents {
+		msgs, err := toChatMessages(content)
+		if err != nil {
+			return nil, err
+		}
+		chatReq.Messages = append(chatReq.Messages, msgs...)
+	}
+	return chatReq, nil
+}
+
+// toChatMessages converts one genai content. Function responses become
+// separate "tool" messages, as the chat completions API expects.
+func toChatMessages(content *genai.Content) ([]chatMessage, error) {
+	if content == nil {
+		return nil, nil
+	}
+
+	role := "user"
+	if content.Role == string(genai.RoleModel) {
+		role = "assistant"
+	}
+	msg := chatMessage{Role: role, Content: joinText(content)}
+
+	var toolMsgs []chatMessage
+	for _, part := range content.Parts {
+		if part == nil {
+			continue
+		}
+		if fc := part.FunctionCall; fc != nil {
+			args, err := json.Marshal(fc.Args)
+			if err != nil {
+				return nil, fmt.Errorf("openai model: failed to marshal function call args: %w", err)
+			}
+			call := chatToolCall{ID: fc.ID, Type: "function"}
+			call.Function.Name = fc.Name
+			call.Function.Arguments = string(args)
+			msg.ToolCalls = append(msg.ToolCalls, call)
+		}
+		if fr := part.FunctionResponse; fr != nil {
+			result, err := json.Marshal(fr.Response)
+			if err != nil {
+				return nil, fmt.Errorf("openai model: failed to marshal function response: %w", err)
+			}
+			toolMsgs = append(toolMsgs, chatMessage{Role: "tool", Content: string(result), ToolCallID: fr.ID})
+		}
+	}
+
+	if msg.Content == "" && len(msg.ToolCalls) == 0 {
+		return toolMsgs, nil
+	}
+	return append([]chatMessage{msg}, toolMsgs...), nil
+}
+
+func fromChatResponse(chatResp chatResponse) (*model.LLMResponse, error) {
+	choice := chatResp.Choices[0]
+
+	content := &genai.Content{Role: string(genai.RoleModel)}
+	if choice.Message.Content != "" {
+		content.Parts = append(content.Parts, &genai.Part{Text: choice.Message.Content})
+	}
+	for _, call := range choice.Message.ToolCalls {
+		var args map[string]any
+		if call.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+				return nil, fmt.Errorf("openai model: invalid arguments for %s: %w", call.Function.Name, err)
+			}
+		}
+		content.Parts = append(content.Parts, &genai.Part{FunctionCall: &genai.FunctionCall{
+			ID:   call.ID,
+			Name: call.Function.Name,
+			Args: args,
+		}})
+	}
+
+	finishReason := genai.FinishReasonOther
+	switch choice.FinishReason {
+	case "stop", "tool_calls":
+		finishReason = genai.FinishReasonStop
+	case "length":
+		finishReason = genai.FinishReasonMaxTokens
+	}
+
+	return &model.LLMResponse{
+		Content: content,
+		UsageMetadata: &genai.GenerateContentResponseUsageMetadata{
+			PromptTokenCount:     chatResp.Usage.PromptTokens,
+			CandidatesTokenCount: chatResp.Usage.CompletionTokens,
+			TotalTokenCount:      chatResp.Usage.TotalTokens,
+		},
+		TurnComplete: true,
+		FinishReason: finishReason,
+	}, nil
+}
+
+func joinText(content *genai.Content) string {
+	var texts []string
+	for _, part := range content.Parts {
+		if part != nil && part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// schemaToJSON converts a genai schema to plain JSON Schema.
+func schemaToJSON(schema *genai.Schema) map[string]any {
+	out := map[string]any{}
+	if schema.Type != "" && schema.Type != genai.TypeUnspecified {
+		out["type"] = strings.ToLower(string(schema.Type))
+	}
+	if schema.Description != "" {
+		out["description"] = schema.Description
+	}
+	if len(schema.Enum) > 0 {
+		out["enum"] = schema.Enum
+	}
+	if schema.Format != "" {
+		out["format"] = schema.Format
+	}
+	if schema.Minimum != nil {
+		out["minimum"] = *schema.Minimum
+	}
+	if schema.Maximum != nil {
+		out["maximum"] = *schema.Maximum
+	}
+	if schema.Items != nil {
+		out["items"] = schemaToJSON(schema.Items)
+	}
+	if len(schema.Properties) > 0 {
+		props := map[string]any{}
+		for key, prop := range schema.Properties {
+			props[key] = schemaToJSON(prop)
+		}
+		out["properties"] = props
+	}
+	if len(schema.Required) > 0 {
+		out["required"] = schema.Required
+	}
+	return out
+}
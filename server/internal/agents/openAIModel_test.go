@@ -0,0 +1,234 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestToChatRequest(t *testing.T) {
+	temperature := float32(0.2)
+	req := &model.LLMRequest{
+		Config: &genai.GenerateContentConfig{
+			SystemInstruction: genai.NewContentFromText("You estimate macros.", genai.RoleUser),
+			Temperature:       &temperature,
+			MaxOutputTokens:   512,
+			Tools: []*genai.Tool{{FunctionDeclarations: []*genai.FunctionDeclaration{{
+				Name:        "lookup",
+				Description: "Looks up a food.",
+				Parameters: &genai.Schema{
+					Type:       genai.TypeObject,
+					Properties: map[string]*genai.Schema{"food": {Type: genai.TypeString}},
+					Required:   []string{"food"},
+				},
+			}}}},
+			ResponseSchema: &genai.Schema{Type: genai.TypeObject, Properties: map[string]*genai.Schema{"name": {Type: genai.TypeString}}},
+		},
+		Contents: []*genai.Content{
+			genai.NewContentFromText("An apple", genai.RoleUser),
+			{Role: string(genai.RoleModel), Parts: []*genai.Part{{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "lookup", Args: map[string]any{"food": "apple"}}}}},
+			{Role: string(genai.RoleUser), Parts: []*genai.Part{{FunctionResponse: &genai.FunctionResponse{ID: "call-1", Name: "lookup", Response: map[string]any{"calories": 95}}}}},
+			genai.NewContentFromText("Done.", genai.RoleModel),
+		},
+	}
+
+	got, err := NewOpenAIModel("llama", "http://localhost:8081/v1/", "").toChatRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Model != "llama" || *got.Temperature != 0.2 || got.MaxTokens != 512 {
+		t.Errorf("request = model %q, temperature %v, max tokens %d", got.Model, *got.Temperature, got.MaxTokens)
+	}
+
+	wantTools := []chatTool{{Type: "function", Function: chatFunction{
+		Name:        "lookup",
+		Description: "Looks up a food.",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"food": map[string]any{"type": "string"}},
+			"required":   []string{"food"},
+		},
+	}}}
+	if !reflect.DeepEqual(got.Tools, wantTools) {
+		t.Errorf("tools = %+v, want %+v", got.Tools, wantTools)
+	}
+
+	wantFormat := &responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"name": map[string]any{"type": "string"}},
+	}}}
+	if !reflect.DeepEqual(got.ResponseFormat, wantFormat) {
+		t.Errorf("response format = %+v, want %+v", got.ResponseFormat, wantFormat)
+	}
+
+	call := chatToolCall{ID: "call-1", Type: "function"}
+	call.Function.Name = "lookup"
+	call.Function.Arguments = `{"food":"apple"}`
+	wantMessages := []chatMessage{
+		{Role: "system", Content: "You estimate macros."},
+		{Role: "user", Content: "An apple"},
+		{Role: "assistant", ToolCalls: []chatToolCall{call}},
+		{Role: "tool", Content: `{"calories":95}`, ToolCallID: "call-1"},
+		{Role: "assistant", Content: "Done."},
+	}
+	if !reflect.DeepEqual(got.Messages, wantMessages) {
+		t.Errorf("messages = %+v, want %+v", got.Messages, wantMessages)
+	}
+}
+
+func TestToChatRequestResponseFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *genai.GenerateContentConfig
+		want   *responseFormat
+	}{
+		{"none", nil, nil},
+		{"JSON schema", &genai.GenerateContentConfig{ResponseJsonSchema: map[string]any{"type": "object"}},
+			&responseFormat{Type: "json_schema", JSONSchema: &jsonSchema{Name: "response", Schema: map[string]any{"type": "object"}}}},
+		{"JSON object", &genai.GenerateContentConfig{ResponseMIMEType: "application/json"}, &responseFormat{Type: "json_object"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewOpenAIModel("llama", "", "").toChatRequest(&model.LLMRequest{Model: "qwen", Config: tt.config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Model != "qwen" {
+				t.Errorf("model = %q, want the request's model", got.Model)
+			}
+			if !reflect.DeepEqual(got.ResponseFormat, tt.want) {
+				t.Errorf("response format = %+v, want %+v", got.ResponseFormat, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromChatResponse(t *testing.T) {
+	decode := func(t *testing.T, data string) chatResponse {
+		t.Helper()
+		var resp chatResponse
+		if err := json.Unmarshal([]byte(data), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	t.Run("text", func(t *testing.T) {
+		resp, err := fromChatResponse(decode(t, `{
+			"choices": [{"message": {"role": "assistant", "content": "An apple has 95 kcal."}, "finish_reason": "stop"}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 8, "total_tokens": 20}
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Content.Role != string(genai.RoleModel) || len(resp.Content.Parts) != 1 || resp.Content.Parts[0].Text != "An apple has 95 kcal." {
+			t.Errorf("content = %+v", resp.Content)
+		}
+		usage := resp.UsageMetadata
+		if usage.PromptTokenCount != 12 || usage.CandidatesTokenCount != 8 || usage.TotalTokenCount != 20 {
+			t.Errorf("usage = %+v", usage)
+		}
+		if resp.FinishReason != genai.FinishReasonStop || !resp.TurnComplete {
+			t.Errorf("finish reason = %v, turn complete = %v", resp.FinishReason, resp.TurnComplete)
+		}
+	})
+
+	t.Run("tool calls", func(t *testing.T) {
+		resp, err := fromChatResponse(decode(t, `{
+			"choices": [{"message": {"role": "assistant", "content": "", "tool_calls": [
+				{"id": "call-1", "type": "function", "function": {"name": "lookup", "arguments": "{\"food\": \"apple\"}"}},
+				{"id": "call-2", "type": "function", "function": {"name": "now", "arguments": ""}}
+			]}, "finish_reason": "tool_calls"}]
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*genai.Part{
+			{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "lookup", Args: map[string]any{"food": "apple"}}},
+			{FunctionCall: &genai.FunctionCall{ID: "call-2", Name: "now"}},
+		}
+		if !reflect.DeepEqual(resp.Content.Parts, want) {
+			t.Errorf("parts = %+v, want %+v", resp.Content.Parts, want)
+		}
+		if resp.FinishReason != genai.FinishReasonStop {
+			t.Errorf("finish reason = %v, want STOP", resp.FinishReason)
+		}
+	})
+
+	t.Run("finish reasons", func(t *testing.T) {
+		for reason, want := range map[string]genai.FinishReason{
+			"length":         genai.FinishReasonMaxTokens,
+			"content_filter": genai.FinishReasonOther,
+		} {
+			resp, err := fromChatResponse(decode(t, `{"choices": [{"message": {"content": "x"}, "finish_reason": "`+reason+`"}]}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.FinishReason != want {
+				t.Errorf("finish reason for %s = %v, want %v", reason, resp.FinishReason, want)
+			}
+		}
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		_, err := fromChatResponse(decode(t, `{"choices": [{"message": {"tool_calls": [
+			{"id": "call-1", "type": "function", "function": {"name": "lookup", "arguments": "{food"}}
+		]}}]}`))
+		if err == nil {
+			t.Error("fromChatResponse accepted invalid arguments")
+		}
+	})
+}
+
+func TestOpenAIModelGenerateContent(t *testing.T) {
+	var gotAuth string
+	var gotReq chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		gotAuth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&gotReq); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "hello"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	llm := NewOpenAIModel("llama", server.URL+"/v1/", "secret")
+	for resp, err := range llm.GenerateContent(context.Background(), &model.LLMRequest{
+		Contents: []*genai.Content{genai.NewContentFromText("hi", genai.RoleUser)},
+	}, false) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Content.Parts[0].Text != "hello" {
+			t.Errorf("reply = %q, want hello", resp.Content.Parts[0].Text)
+		}
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("Authorization = %q, want the API key", gotAuth)
+	}
+	if gotReq.Model != "llama" || len(gotReq.Messages) != 1 || gotReq.Messages[0].Content != "hi" {
+		t.Errorf("request = %+v", gotReq)
+	}
+
+	empty := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"choices": []}`))
+	}))
+	defer empty.Close()
+	for _, err := range NewOpenAIModel("llama", empty.URL, "").GenerateContent(context.Background(), &model.LLMRequest{}, false) {
+		if err == nil {
+			t.Error("GenerateContent accepted a response without choices")
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"sync"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// ScriptedTurn is one canned reply of a ScriptedModel.
+type ScriptedTurn struct {
+	Text         string              `json:"text,omitempty"`
+	FunctionCall *genai.FunctionCall `json:"function_call,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// ScriptedModel is a deterministic model.LLM for tests and offline runs.
+// It replies with its turns in order and starts over once they run out.
+// Without turns, it replies with a placeholder shaped like the requested
+// response schema, or a fixed text when there is none.
+type ScriptedModel struct {
+	name string
+
+	mu       sync.Mutex
+	turns    []ScriptedTurn
+	next     int
+	requests []*model.LLMRequest
+}
+
+// NewScriptedModel creates a scripted model that replies with the given turns.
+func NewScriptedModel(name string, turns ...ScriptedTurn) *ScriptedModel {
+	return &ScriptedModel{name: name, turns: turns}
+}
+
+// LoadScript reads scripted turns from a JSON array file.
+func LoadScript(path string) ([]ScriptedTurn, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model script: %w", err)
+	}
+	var turns []ScriptedTurn
+	if err := json.Unmarshal(data, &turns); err != nil {
+		return nil, fmt.Errorf("failed to parse model script: %w", err)
+	}
+	return turns, nil
+}
+
+// Name implements model.LLM.
+func (m *ScriptedModel) Name() string {
+	return m.name
+}
+
+// Requests returns every request the model has received, in order.
+func (m *ScriptedModel) Requests() []*model.LLMRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*model.LLMRequest(nil), m.requests...)
+}
+
+// GenerateContent implements model.LLM. Streaming yields the same single response.
+func (m *ScriptedModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		turn := m.nextTurn(req)
+		if turn.Error != "" {
+			yield(nil, errors.New(turn.Error))
+			return
+		}
+
+		part := &genai.Part{Text: turn.Text}
+		if turn.FunctionCall != nil {
+			part = &genai.Part{FunctionCall: turn.FunctionCall}
+		}
+		yield(&model.LLMResponse{
+			Content:      &genai.Content{Role: string(genai.RoleModel), Parts: []*genai.Part{part}},
+			TurnComplete: true,
+			FinishReason: genai.FinishReasonStop,
+		}, nil)
+	}
+}
+
+func (m *ScriptedModel) nextTurn(req *model.LLMRequest) ScriptedTurn {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests = append(m.requests, req)
+	if len(m.turns) == 0 {
+		return placeholderTurn(req)
+	}
+	turn := m.turns[m.next%len(m.turns)]
+	m.next++
+	return turn
+}
+
+// placeholderTurn answers with the zero value of the response schema, if any.
+func placeholderTurn(req *model.LLMRequest) ScriptedTurn {
+	if req.Config == nil || req.Config.ResponseSchema == nil {
+		return ScriptedTurn{Text: "This is a scripted response."}
+	}
+	data, err := json.Marshal(placeholderValue(req.Config.ResponseSchema))
+	if err != nil {
+		return ScriptedTurn{Error: fmt.Sprintf("failed to build placeholder: %v", err)}
+	}
+	return ScriptedTurn{Text: string(data)}
+}
+
+func placeholderValue(schema *genai.Schema) any {
+	switch schema.Type {
+	case genai.TypeObject:
+		obj := map[string]any{}
+		for _, key := range schema.Required {
+			if prop, ok := schema.Properties[key]; ok {
+				obj[key] = placeholderValue(prop)
+			}
+		}
+		return obj
+	case genai.TypeArray:
+		return []any{}
+	case genai.TypeNumber, genai.TypeInteger:
+		return 0
+	case genai.TypeBoolean:
+		return false
+	case genai.TypeString:
+		if len(schema.Enum) > 0 {
+			return schema.Enum[0]
+		}
+		return ""
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,218 @@
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/models"
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+	"google.golang.org/genai"
+)
+
+// runAgent runs a in a new in-memory session and returns the text of its
+// final reply.
+func runAgent(t *testing.T, a agent.Agent, text string) string {
+	t.Helper()
+	ctx := context.Background()
+	sessionService := session.InMemoryService()
+	if _, err := sessionService.Create(ctx, &session.CreateRequest{AppName: "test", UserID: "u1", SessionID: "s1"}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := runner.New(runner.Config{AppName: "test", Agent: a, SessionService: sessionService})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var final string
+	for event, err := range r.Run(ctx, "u1", "s1", genai.NewContentFromText(text, genai.RoleUser), agent.RunConfig{}) {
+		if err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		if event.Content != nil && len(event.Content.Parts) > 0 && event.Content.Parts[0].Text != "" {
+			final = event.Content.Parts[0].Text
+		}
+	}
+	return final
+}
+
+func TestScriptedModelDrivesToolCalls(t *testing.T) {
+	type lookupArgs struct {
+		Food string `json:"food"`
+	}
+	type lookupResult struct {
+		Calories float64 `json:"calories"`
+	}
+	var looked []string
+	lookup, err := functiontool.New(functiontool.Config{Name: "lookup", Description: "Looks up a food."},
+		func(ctx tool.Context, args lookupArgs) lookupResult {
+			looked = append(looked, args.Food)
+			return lookupResult{Calories: 95}
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	llm := NewScriptedModel("scripted",
+		ScriptedTurn{FunctionCall: &genai.FunctionCall{ID: "call-1", Name: "lookup", Args: map[string]any{"food": "apple"}}},
+		ScriptedTurn{Text: "An apple has 95 kcal."},
+	)
+	a, err := llmagent.New(llmagent.Config{
+		Name:        "assistant",
+		Model:       llm,
+		Instruction: "Answer questions about food.",
+		Tools:       []tool.Tool{lookup},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := runAgent(t, a, "How many calories are in an apple?"); got != "An apple has 95 kcal." {
+		t.Errorf("final reply = %q", got)
+	}
+	if len(looked) != 1 || looked[0] != "apple" {
+		t.Errorf("tool calls = %v, want [apple]", looked)
+	}
+
+	requests := llm.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("model got %d requests, want 2", len(requests))
+	}
+	last := requests[1].Contents[len(requests[1].Contents)-1]
+	if len(last.Parts) == 0 || last.Parts[0].FunctionResponse == nil || last.Parts[0].FunctionResponse.Name != "lookup" {
+		t.Errorf("second request does not end with the tool response: %+v", last)
+	}
+}
+
+func TestMacroEstimatorWithScriptedModel(t *testing.T) {
+	script := `[{"text": "{\"name\": \"Peanut butter toast\", \"meal_type\": \"breakfast\", \"macros\": {\"calories\": 290, \"protein\": 10, \"carbs\": 28, \"fat\": 16}, \"assumptions\": [{\"category\": \"portion\", \"field\": \"peanut_butter_amount\", \"assumed_value\": 1, \"unit\": \"tbsp\"}, {\"category\": \"portion\", \"field\": \"bread\", \"assumed_value\": 30}]}"}]`
+	path := filepath.Join(t.TempDir(), "script.json")
+	if err := os.WriteFile(path, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := MacroEstimator(config.AgentsConfig{
+		Model: config.ModelConfig{Provider: ProviderFake, FakeScript: path},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := runAgent(t, a, "peanut butter toast")
+
+	var payload models.NutritionPayload
+	if err := json.Unmarshal([]byte(reply), &payload); err != nil {
+		t.Fatalf("reply is not a nutrition payload: %v\n%s", err, reply)
+	}
+	if payload.Name != "Peanut butter toast" || payload.Macros.Calories != 290 {
+		t.Errorf("payload = %+v", payload)
+	}
+	if len(payload.Assumptions) != 2 {
+		t.Fatalf("got %d assumptions, want 2", len(payload.Assumptions))
+	}
+	// The agent numbers assumptions and defaults their unit to grams.
+	if a := payload.Assumptions[0]; a.ID != "A1" || a.Unit != "tbsp" {
+		t.Errorf("first assumption = %+v, want ID A1 and unit tbsp", a)
+	}
+	if a := payload.Assumptions[1]; a.ID != "A2" || a.Unit != "g" {
+		t.Errorf("second assumption = %+v, want ID A2 and unit g", a)
+	}
+	if payload.Meta == nil || payload.Meta.SchemaVersion == "" || !strings.HasPrefix(payload.Meta.AgentVersion, "macro_estimator") {
+		t.Errorf("meta = %+v, want the definition stamped", payload.Meta)
+	}
+}
+
+func TestScriptedModel(t *testing.T) {
+	generate := func(llm *ScriptedModel, req *model.LLMRequest) (*model.LLMResponse, error) {
+		for resp, err := range llm.GenerateContent(context.Background(), req, false) {
+			return resp, err
+		}
+		t.Fatal("no response")
+		return nil, nil
+	}
+
+	t.Run("turns repeat", func(t *testing.T) {
+		llm := NewScriptedModel("scripted", ScriptedTurn{Text: "one"}, ScriptedTurn{Text: "two"})
+		var got []string
+		for range 3 {
+			resp, err := generate(llm, &model.LLMRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, resp.Content.Parts[0].Text)
+		}
+		if strings.Join(got, ",") != "one,two,one" {
+			t.Errorf("replies = %v, want one, two, one", got)
+		}
+	})
+
+	t.Run("error turn", func(t *testing.T) {
+		llm := NewScriptedModel("scripted", ScriptedTurn{Error: "quota exceeded"})
+		if _, err := generate(llm, &model.LLMRequest{}); err == nil || err.Error() != "quota exceeded" {
+			t.Errorf("error = %v, want quota exceeded", err)
+		}
+	})
+
+	t.Run("placeholder for schema", func(t *testing.T) {
+		llm := NewScriptedModel("scripted")
+		resp, err := generate(llm, &model.LLMRequest{Config: &genai.GenerateContentConfig{
+			ResponseSchema: &genai.Schema{
+				Type:     genai.TypeObject,
+				Required: []string{"name", "meal_type", "macros", "assumptions"},
+				Properties: map[string]*genai.Schema{
+					"name":        {Type: genai.TypeString},
+					"meal_type":   {Type: genai.TypeString, Enum: []string{"breakfast", "lunch"}},
+					"macros":      {Type: genai.TypeObject, Required: []string{"calories"}, Properties: map[string]*genai.Schema{"calories": {Type: genai.TypeNumber}}},
+					"assumptions": {Type: genai.TypeArray, Items: &genai.Schema{Type: genai.TypeObject}},
+					"notes":       {Type: genai.TypeString},
+				},
+			},
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := `{"assumptions":[],"macros":{"calories":0},"meal_type":"breakfast","name":""}`
+		if got := resp.Content.Parts[0].Text; got != want {
+			t.Errorf("placeholder = %s, want %s", got, want)
+		}
+	})
+
+	t.Run("placeholder without schema", func(t *testing.T) {
+		resp, err := generate(NewScriptedModel("scripted"), &model.LLMRequest{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Content.Parts[0].Text == "" {
+			t.Error("placeholder is empty")
+		}
+	})
+}
+
+func TestLoadScript(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "script.json")
+	script := `[{"text": "hi"}, {"function_call": {"name": "lookup", "args": {"food": "apple"}}}, {"error": "boom"}]`
+	if err := os.WriteFile(path, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	turns, err := LoadScript(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(turns) != 3 || turns[0].Text != "hi" || turns[1].FunctionCall.Name != "lookup" || turns[2].Error != "boom" {
+		t.Errorf("turns = %+v", turns)
+	}
+
+	if _, err := LoadScript(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadScript of a missing file succeeded")
+	}
+}
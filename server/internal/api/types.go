@@ -2,20 +2,48 @@ package api
 
 import "github.com/simhozebs/mugo/internal/models"
 
+// NutritionRequestBody is the body of a nutrition estimate request.
+type NutritionRequestBody struct {
+	UserID    string `json:"user_id" example:"user_12345" doc:"User ID of the requester"`
+	SessionID string `json:"session_id" example:"session_12345" doc:"Session ID for the conversation"`
+	Text      string `json:"text" example:"I ate a chicken sandwich" doc:"Description of food eaten"`
+
+	DietaryPreferences []string `json:"dietary_preferences,omitempty" example:"[\"vegan\"]" doc:"Dietary preferences; defaults to the user's stored profile"`
+	Allergens          []string `json:"allergens,omitempty" example:"[\"peanuts\"]" doc:"Allergens; defaults to the user's stored profile"`
+}
+
 // NutritionRequest is the request body for the nutrition endpoint.
 type NutritionRequest struct {
-	Body struct {
-		UserID    string `json:"user_id" example:"user_12345" doc:"User ID of the requester"`
-		SessionID string `json:"session_id" example:"session_12345" doc:"Session ID for the conversation"`
-		Text      string `json:"text" example:"I ate a chicken sandwich" doc:"Description of food eaten"`
-	}
+	IdempotencyKey string `header:"Idempotency-Key" example:"7c4a8d09-ca37-4c1e-a6a4-6f1b2f1e5d3a" doc:"Optional key that makes retries of this request return the first result instead of logging the meal again"`
+
+	Body NutritionRequestBody
+}
+
+// NutritionResponseBody is the result of a nutrition estimate.
+type NutritionResponseBody struct {
+	Analysis  models.NutritionPayload `json:"analysis" doc:"Nutritional analysis and assumptions"`
+	SessionID string                  `json:"session_id" example:"session_67890" doc:"Session ID for continued conversation"`
 }
 
 // NutritionResponse is the response body for the nutrition endpoint.
 type NutritionResponse struct {
+	Body NutritionResponseBody
+}
+
+// NutritionJobRequest is the request body for the nutrition job endpoint.
+type NutritionJobRequest struct {
+	IdempotencyKey string `header:"Idempotency-Key" example:"7c4a8d09-ca37-4c1e-a6a4-6f1b2f1e5d3a" doc:"Optional key that makes retries of this request return the first job instead of enqueueing another"`
+
+	Body struct {
+		NutritionRequestBody
+		CallbackURL string `json:"callback_url,omitempty" format:"uri" pattern:"^https?://" example:"https://example.com/hooks/mugo" doc:"URL to POST the finished job to"`
+	}
+}
+
+// JobResponse is the response body for job endpoints.
+type JobResponse struct {
 	Body struct {
-		Analysis  models.NutritionPayload `json:"analysis" doc:"Nutritional analysis and assumptions"`
-		SessionID string                  `json:"session_id" example:"session_67890" doc:"Session ID for continued conversation"`
+		Job *models.Job `json:"job" doc:"The job; its result holds the endpoint's response body once it succeeded"`
 	}
 }
 
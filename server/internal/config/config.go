@@ -0,0 +1,311 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	AppName = "mugo"
+)
+
+// Config is the configuration of the mugo binaries.
+//
+// Load fills it from defaults, then an optional YAML or TOML file, then
+// environment variables, each overriding the previous. The env tag of a field
+// names its environment variable.
+type Config struct {
+	// Env is the deployment environment, e.g. "production".
+	Env string `yaml:"env" toml:"env" env:"APP_ENV"`
+
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	ADK         ADKConfig         `yaml:"adk" toml:"adk"`
+	Agents      AgentsConfig      `yaml:"agents" toml:"agents"`
+	Jobs        JobsConfig        `yaml:"jobs" toml:"jobs"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Admin       AdminConfig       `yaml:"admin" toml:"admin"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+	Telemetry   TelemetryConfig   `yaml:"telemetry" toml:"telemetry"`
+}
+
+// ServerConfig configures the HTTP server of the API.
+type ServerConfig struct {
+	Port int `yaml:"port" toml:"port" env:"PORT"`
+	// ReadTimeout bounds reading a request, body included.
+	ReadTimeout time.Duration `yaml:"read_timeout" toml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	// WriteTimeout bounds answering a request. Agent calls are retried, so it
+	// should exceed the ADK timeout.
+	WriteTimeout time.Duration `yaml:"write_timeout" toml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	// IdleTimeout bounds how long idle keep-alive connections stay open.
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout bounds waiting for in-flight requests and running jobs
+	// on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	// TLSCertFile and TLSKeyFile, when both set, make the server serve HTTPS.
+	TLSCertFile string `yaml:"tls_cert_file" toml:"tls_cert_file" env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `yaml:"tls_key_file" toml:"tls_key_file" env:"TLS_KEY_FILE"`
+}
+
+// DatabaseConfig configures the Postgres connection pool.
+type DatabaseConfig struct {
+	URL               Secret        `yaml:"url" toml:"url" env:"DATABASE_URL"`
+	MinConns          int           `yaml:"min_conns" toml:"min_conns" env:"DB_MIN_CONNS"`
+	MaxConns          int           `yaml:"max_conns" toml:"max_conns" env:"DB_MAX_CONNS"`
+	MaxConnLifetime   time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" env:"DB_MAX_CONN_LIFETIME"`
+	MaxConnIdleTime   time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" env:"DB_MAX_CONN_IDLE_TIME"`
+	HealthCheckPeriod time.Duration `yaml:"health_check_period" toml:"health_check_period" env:"DB_HEALTH_CHECK_PERIOD"`
+	ConnectTimeout    time.Duration `yaml:"connect_timeout" toml:"connect_timeout" env:"DB_CONNECT_TIMEOUT"`
+	// FailFast makes the binaries exit when the database cannot be reached
+	// instead of running without it.
+	FailFast bool `yaml:"fail_fast" toml:"fail_fast" env:"FAIL_FAST_ON_DB_ERROR"`
+}
+
+// ADKConfig configures the client of the ADK server.
+type ADKConfig struct {
+	// ServerURL includes the /api path, e.g. "http://localhost:8080/api".
+	ServerURL string `yaml:"server_url" toml:"server_url" env:"ADK_SERVER_URL"`
+	// Timeout bounds a single request to the ADK server.
+	Timeout             time.Duration `yaml:"timeout" toml:"timeout" env:"ADK_TIMEOUT"`
+	RetryMaxAttempts    int           `yaml:"retry_max_attempts" toml:"retry_max_attempts" env:"ADK_RETRY_MAX_ATTEMPTS"`
+	RetryInitialBackoff time.Duration `yaml:"retry_initial_backoff" toml:"retry_initial_backoff" env:"ADK_RETRY_INITIAL_BACKOFF"`
+	RetryMaxBackoff     time.Duration `yaml:"retry_max_backoff" toml:"retry_max_backoff" env:"ADK_RETRY_MAX_BACKOFF"`
+	// BreakerThreshold is how many consecutive failures open the circuit
+	// breaker; 0 disables it.
+	BreakerThreshold int           `yaml:"breaker_threshold" toml:"breaker_threshold" env:"ADK_BREAKER_THRESHOLD"`
+	BreakerCooldown  time.Duration `yaml:"breaker_cooldown" toml:"breaker_cooldown" env:"ADK_BREAKER_COOLDOWN"`
+}
+
+// AgentsConfig configures which agents run and how.
+type AgentsConfig struct {
+	// Runner is "http" to talk to a separate ADK server or "embedded" to run
+	// the agents in the API process.
+	Runner string `yaml:"runner" toml:"runner" env:"AGENT_RUNNER"`
+	// Mapping maps API route names, e.g. "nutrition", to ADK app names, e.g.
+	// "macro_estimator". In the environment it is written as
+	// "nutrition=macro_estimator,weather=hello_time_agent" and overrides
+	// single routes.
+	Mapping map[string]string `yaml:"mapping" toml:"mapping" env:"AGENT_MAPPING"`
+	// DefinitionsDir holds agent definition files that override the ones
+	// embedded in the binary.
+	DefinitionsDir string `yaml:"definitions_dir" toml:"definitions_dir" env:"AGENT_DEFINITIONS_DIR"`
+
+	Model ModelConfig `yaml:"model" toml:"model"`
+}
+
+// ModelConfig configures the LLM the agents run against.
+type ModelConfig struct {
+	// Provider is "gemini", "openai" (any OpenAI-compatible endpoint) or "fake".
+	Provider string `yaml:"provider" toml:"provider" env:"MODEL_PROVIDER"`
+	// Name, when set, replaces the model named in every agent definition.
+	Name         string `yaml:"name" toml:"name" env:"MODEL_NAME"`
+	GoogleAPIKey Secret `yaml:"google_api_key" toml:"google_api_key" env:"GOOGLE_API_KEY"`
+	// BaseURL and APIKey configure the OpenAI-compatible endpoint.
+	BaseURL string `yaml:"base_url" toml:"base_url" env:"MODEL_BASE_URL"`
+	APIKey  Secret `yaml:"api_key" toml:"api_key" env:"MODEL_API_KEY"`
+	// FakeScript is a JSON script for the fake model. Without one it answers
+	// with schema-shaped placeholders.
+	FakeScript string `yaml:"fake_script" toml:"fake_script" env:"MODEL_FAKE_SCRIPT"`
+	// Pricing maps model names to their prices, used to estimate the cost of
+	// LLM calls. It can only be set in a file.
+	Pricing map[string]ModelPrice `yaml:"pricing" toml:"pricing"`
+}
+
+// ModelPrice is the price of a model in US dollars per million tokens.
+type ModelPrice struct {
+	InputPerMillion float64 `yaml:"input_per_million" toml:"input_per_million"`
+	// OutputPerMillion also applies to thinking tokens.
+	OutputPerMillion float64 `yaml:"output_per_million" toml:"output_per_million"`
+}
+
+// JobsConfig configures the background job workers of the API.
+type JobsConfig struct {
+	// Workers is how many jobs run at once; 0 disables the workers.
+	Workers      int           `yaml:"workers" toml:"workers" env:"JOB_WORKERS"`
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" env:"JOB_POLL_INTERVAL"`
+	// Timeout bounds a single job attempt.
+	Timeout     time.Duration `yaml:"timeout" toml:"timeout" env:"JOB_TIMEOUT"`
+	MaxAttempts int           `yaml:"max_attempts" toml:"max_attempts" env:"JOB_MAX_ATTEMPTS"`
+	// CallbackHosts, when set, are the only hosts job callbacks may be sent
+	// to; they may also resolve to private addresses. Without them any public
+	// address is allowed. It can only be set in a file.
+	CallbackHosts []string `yaml:"callback_hosts" toml:"callback_hosts"`
+}
+
+// IdempotencyConfig configures Idempotency-Key handling.
+type IdempotencyConfig struct {
+	// KeyTTL is how long a key and its stored response are kept.
+	KeyTTL time.Duration `yaml:"key_ttl" toml:"key_ttl" env:"IDEMPOTENCY_KEY_TTL"`
+}
+
+// RateLimitConfig configures the limits on agent calls. Each user has a token
+// bucket and a daily quota set by their plan, and each client IP a token
+// bucket shared by all its users.
+type RateLimitConfig struct {
+	// Enabled turns the limits on. They are off by default: users are told
+	// apart by the user_id they send, which nothing authenticates, so clients
+	// sharing an ID would share its limits.
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"RATE_LIMIT_ENABLED"`
+	// IPRequestsPerMinute and IPBurst size the token bucket of a client IP.
+	IPRequestsPerMinute int `yaml:"ip_requests_per_minute" toml:"ip_requests_per_minute" env:"RATE_LIMIT_IP_REQUESTS_PER_MINUTE"`
+	IPBurst             int `yaml:"ip_burst" toml:"ip_burst" env:"RATE_LIMIT_IP_BURST"`
+	// DefaultPlan applies to users without a plan of their own.
+	DefaultPlan string `yaml:"default_plan" toml:"default_plan" env:"RATE_LIMIT_DEFAULT_PLAN"`
+	// Plans maps plan names to their limits. It can only be set in a file.
+	Plans map[string]PlanConfig `yaml:"plans" toml:"plans"`
+}
+
+// PlanConfig holds the limits of a plan.
+type PlanConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute" toml:"requests_per_minute"`
+	Burst             int `yaml:"burst" toml:"burst"`
+	// DailyAgentCalls is how many agent calls a user can make per UTC day;
+	// 0 means no limit.
+	DailyAgentCalls int `yaml:"daily_agent_calls" toml:"daily_agent_calls"`
+}
+
+// AdminConfig configures the admin endpoints.
+type AdminConfig struct {
+	// Token is the bearer token admin requests must send. Without one the
+	// admin endpoints are not served.
+	Token Secret `yaml:"token" toml:"token" env:"ADMIN_TOKEN"`
+}
+
+// LoggingConfig configures log output.
+type LoggingConfig struct {
+	// Format is "json" or "text". It defaults to "json" in production.
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+	// Level is "debug", "info", "warn" or "error".
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	// UserContent is how much of users' free text, such as meal descriptions
+	// and chat messages, is logged: "full", "truncate" (the first few
+	// characters) or "redact" (only the length).
+	UserContent string `yaml:"user_content" toml:"user_content" env:"LOG_USER_CONTENT"`
+}
+
+// TelemetryConfig configures trace and metric export.
+type TelemetryConfig struct {
+	// Exporter is "otlp" to send to the collector set by the standard
+	// OTEL_EXPORTER_OTLP_* variables, "stdout" to print for local runs, or
+	// "none".
+	Exporter string `yaml:"exporter" toml:"exporter" env:"TELEMETRY_EXPORTER"`
+}
+
+// Default returns the configuration used for everything that is not set.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8888,
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    3 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MinConns:          5,
+			MaxConns:          25,
+			MaxConnLifetime:   1 * time.Hour,
+			MaxConnIdleTime:   30 * time.Minute,
+			HealthCheckPeriod: 1 * time.Minute,
+			ConnectTimeout:    5 * time.Second,
+			FailFast:          true,
+		},
+		ADK: ADKConfig{
+			ServerURL:           "http://localhost:8080/api",
+			Timeout:             60 * time.Second,
+			RetryMaxAttempts:    3,
+			RetryInitialBackoff: 200 * time.Millisecond,
+			RetryMaxBackoff:     5 * time.Second,
+			BreakerThreshold:    5,
+			BreakerCooldown:     30 * time.Second,
+		},
+		Agents: AgentsConfig{
+			Runner: "http",
+			Mapping: map[string]string{
+				"nutrition": "macro_estimator",
+				"weather":   "hello_time_agent",
+				"echo":      "echo_agent",
+				"coach":     "nutrition_coach",
+			},
+			Model: ModelConfig{
+				Provider: "gemini",
+				BaseURL:  "http://localhost:11434/v1",
+				Pricing: map[string]ModelPrice{
+					"gemini-2.5-pro":        {InputPerMillion: 1.25, OutputPerMillion: 10},
+					"gemini-2.5-flash":      {InputPerMillion: 0.30, OutputPerMillion: 2.50},
+					"gemini-2.5-flash-lite": {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+					"gemini-2.0-flash":      {InputPerMillion: 0.10, OutputPerMillion: 0.40},
+				},
+			},
+		},
+		Jobs: JobsConfig{
+			Workers:      2,
+			PollInterval: 1 * time.Second,
+			Timeout:      5 * time.Minute,
+			MaxAttempts:  3,
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL: 24 * time.Hour,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:             false,
+			IPRequestsPerMinute: 120,
+			IPBurst:             30,
+			DefaultPlan:         "free",
+			Plans: map[string]PlanConfig{
+				"free":      {RequestsPerMinute: 10, Burst: 5, DailyAgentCalls: 100},
+				"pro":       {RequestsPerMinute: 60, Burst: 20, DailyAgentCalls: 2000},
+				"unlimited": {RequestsPerMinute: 600, Burst: 100},
+			},
+		},
+		Logging: LoggingConfig{
+			Level:       "info",
+			UserContent: "redact",
+		},
+		Telemetry: TelemetryConfig{
+			Exporter: "none",
+		},
+	}
+}
+
+// String returns the configuration as YAML, with secrets redacted.
+func (c *Config) String() string {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("<invalid config: %v>", err)
+	}
+	return string(data)
+}
+
+// Secret is a configuration value that must not be printed or logged, such
+// as a password or an API key. Use Value to read it.
+type Secret string
+
+const redacted = "[redacted]"
+
+// Value returns the secret itself.
+func (s Secret) Value() string {
+	return string(s)
+}
+
+// String implements fmt.Stringer.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+// LogValue implements slog.LogValuer.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
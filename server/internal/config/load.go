@@ -0,0 +1,331 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError lists every problem found while loading a configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load loads the configuration from the file at path, if any, and from the
+// environment. An empty path falls back to the CONFIG_FILE environment
+// variable; the file is read as TOML if its name ends in .toml and as YAML
+// otherwise. Every invalid or unknown key is reported in one
+// *ValidationError.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	var problems []string
+
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path != "" {
+		fileProblems, err := cfg.loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, fileProblems...)
+	}
+
+	problems = append(problems, cfg.loadEnv()...)
+	cfg.applyDerivedDefaults()
+	problems = append(problems, cfg.validate()...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return cfg, nil
+}
+
+// loadFile decodes the file over the configuration and returns its unknown
+// or badly typed keys.
+func (c *Config) loadFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		meta, err := toml.Decode(string(data), c)
+		if err != nil {
+			return []string{fmt.Sprintf("%s: %v", path, err)}, nil
+		}
+		var problems []string
+		for _, key := range meta.Undecoded() {
+			problems = append(problems, fmt.Sprintf("%s: unknown key %s", path, key))
+		}
+		return problems, nil
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			problems := make([]string, 0, len(typeErr.Errors))
+			for _, msg := range typeErr.Errors {
+				problems = append(problems, fmt.Sprintf("%s: %s", path, msg))
+			}
+			return problems, nil
+		}
+		return []string{fmt.Sprintf("%s: %v", path, err)}, nil
+	}
+	return nil, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// loadEnv applies the environment variables named by env tags and returns
+// the ones that could not be parsed.
+func (c *Config) loadEnv() []string {
+	var problems []string
+	for _, f := range c.fields() {
+		raw, ok := os.LookupEnv(f.env)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setFromString(f.value, raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", f.env, err))
+		}
+	}
+	return problems
+}
+
+func setFromString(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Map:
+		// "key=value,key=value" entries override single keys.
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for _, entry := range strings.Split(raw, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || key == "" {
+				return fmt.Errorf("invalid entry %q, want key=value", entry)
+			}
+			v.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(value))
+		}
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// field is a configuration value with its file key and environment variable.
+type field struct {
+	key   string
+	env   string
+	value reflect.Value
+}
+
+// fields lists every configuration value that has an environment variable.
+func (c *Config) fields() []field {
+	var fields []field
+	var walk func(v reflect.Value, prefix string)
+	walk = func(v reflect.Value, prefix string) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			key := prefix + strings.Split(sf.Tag.Get("yaml"), ",")[0]
+			if sf.Type.Kind() == reflect.Struct && sf.Type != durationType {
+				walk(v.Field(i), key+".")
+				continue
+			}
+			if env := sf.Tag.Get("env"); env != "" {
+				fields = append(fields, field{key: key, env: env, value: v.Field(i)})
+			}
+		}
+	}
+	walk(reflect.ValueOf(c).Elem(), "")
+	return fields
+}
+
+// applyDerivedDefaults fills defaults that depend on other values.
+func (c *Config) applyDerivedDefaults() {
+	if c.Logging.Format == "" {
+		if c.Env == "production" {
+			c.Logging.Format = "json"
+		} else {
+			c.Logging.Format = "text"
+		}
+	}
+}
+
+// validate returns every invalid value, named by file key and environment
+// variable.
+func (c *Config) validate() []string {
+	envs := map[string]string{}
+	for _, f := range c.fields() {
+		envs[f.key] = f.env
+	}
+	var problems []string
+	fail := func(key, format string, args ...any) {
+		if env := envs[key]; env != "" {
+			key += " (" + env + ")"
+		}
+		problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+	}
+	oneOf := func(key, value string, allowed ...string) {
+		if !slices.Contains(allowed, value) {
+			fail(key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+		}
+	}
+	positive := func(key string, d time.Duration) {
+		if d <= 0 {
+			fail(key, "must be positive, got %s", d)
+		}
+	}
+	validURL := func(key, value string) {
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail(key, "must be an http or https URL, got %q", value)
+		}
+	}
+
+	s := c.Server
+	if s.Port < 1 || s.Port > 65535 {
+		fail("server.port", "must be between 1 and 65535, got %d", s.Port)
+	}
+	positive("server.read_timeout", s.ReadTimeout)
+	positive("server.write_timeout", s.WriteTimeout)
+	positive("server.idle_timeout", s.IdleTimeout)
+	positive("server.shutdown_timeout", s.ShutdownTimeout)
+	if (s.TLSCertFile == "") != (s.TLSKeyFile == "") {
+		fail("server.tls_key_file", "must be set together with server.tls_cert_file")
+	}
+
+	d := c.Database
+	if d.MinConns < 0 {
+		fail("database.min_conns", "must not be negative, got %d", d.MinConns)
+	}
+	if d.MaxConns < 1 {
+		fail("database.max_conns", "must be at least 1, got %d", d.MaxConns)
+	} else if d.MaxConns < d.MinConns {
+		fail("database.max_conns", "must be at least database.min_conns (%d), got %d", d.MinConns, d.MaxConns)
+	}
+	positive("database.max_conn_lifetime", d.MaxConnLifetime)
+	positive("database.max_conn_idle_time", d.MaxConnIdleTime)
+	positive("database.health_check_period", d.HealthCheckPeriod)
+	positive("database.connect_timeout", d.ConnectTimeout)
+
+	a := c.ADK
+	validURL("adk.server_url", a.ServerURL)
+	positive("adk.timeout", a.Timeout)
+	if a.RetryMaxAttempts < 1 {
+		fail("adk.retry_max_attempts", "must be at least 1, got %d", a.RetryMaxAttempts)
+	}
+	positive("adk.retry_initial_backoff", a.RetryInitialBackoff)
+	if a.RetryMaxBackoff < a.RetryInitialBackoff {
+		fail("adk.retry_max_backoff", "must be at least adk.retry_initial_backoff (%s), got %s", a.RetryInitialBackoff, a.RetryMaxBackoff)
+	}
+	if a.BreakerThreshold < 0 {
+		fail("adk.breaker_threshold", "must not be negative, got %d", a.BreakerThreshold)
+	}
+	positive("adk.breaker_cooldown", a.BreakerCooldown)
+
+	ag := c.Agents
+	oneOf("agents.runner", ag.Runner, "http", "embedded")
+	for _, route := range []string{"nutrition", "weather", "echo", "coach"} {
+		if ag.Mapping[route] == "" {
+			fail("agents.mapping", "needs an app for the %q route", route)
+		}
+	}
+	oneOf("agents.model.provider", ag.Model.Provider, "gemini", "openai", "fake")
+	if ag.Model.Provider == "openai" {
+		validURL("agents.model.base_url", ag.Model.BaseURL)
+	}
+	for _, name := range slices.Sorted(maps.Keys(ag.Model.Pricing)) {
+		price, key := ag.Model.Pricing[name], "agents.model.pricing."+name
+		if price.InputPerMillion < 0 || price.OutputPerMillion < 0 {
+			fail(key, "prices must not be negative")
+		}
+	}
+
+	j := c.Jobs
+	if j.Workers < 0 {
+		fail("jobs.workers", "must not be negative, got %d", j.Workers)
+	}
+	positive("jobs.poll_interval", j.PollInterval)
+	positive("jobs.timeout", j.Timeout)
+	if j.MaxAttempts < 1 {
+		fail("jobs.max_attempts", "must be at least 1, got %d", j.MaxAttempts)
+	}
+
+	positive("idempotency.key_ttl", c.Idempotency.KeyTTL)
+
+	rl := c.RateLimit
+	if rl.IPRequestsPerMinute < 1 {
+		fail("rate_limit.ip_requests_per_minute", "must be at least 1, got %d", rl.IPRequestsPerMinute)
+	}
+	if rl.IPBurst < 1 {
+		fail("rate_limit.ip_burst", "must be at least 1, got %d", rl.IPBurst)
+	}
+	if _, ok := rl.Plans[rl.DefaultPlan]; !ok {
+		fail("rate_limit.default_plan", "must name one of rate_limit.plans, got %q", rl.DefaultPlan)
+	}
+	for _, name := range slices.Sorted(maps.Keys(rl.Plans)) {
+		plan, key := rl.Plans[name], "rate_limit.plans."+name
+		if plan.RequestsPerMinute < 1 {
+			fail(key+".requests_per_minute", "must be at least 1, got %d", plan.RequestsPerMinute)
+		}
+		if plan.Burst < 1 {
+			fail(key+".burst", "must be at least 1, got %d", plan.Burst)
+		}
+		if plan.DailyAgentCalls < 0 {
+			fail(key+".daily_agent_calls", "must not be negative, got %d", plan.DailyAgentCalls)
+		}
+	}
+
+	l := c.Logging
+	oneOf("logging.format", l.Format, "json", "text")
+	oneOf("logging.level", strings.ToLower(l.Level), "debug", "info", "warn", "error")
+	oneOf("logging.user_content", l.UserContent, "full", "truncate", "redact")
+
+	oneOf("telemetry.exporter", c.Telemetry.Exporter, "otlp", "stdout", "none")
+
+	return problems
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/simhozebs/mugo/internal/config"
 	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
@@ -14,23 +15,23 @@ type Pool struct {
 	*dbgenerated.Queries
 }
 
-func NewPool(ctx context.Context) (*Pool, error) {
-	databaseURL := config.GetDatabaseURL()
-	if databaseURL == "" {
+func NewPool(ctx context.Context, cfg config.DatabaseConfig) (*Pool, error) {
+	if cfg.URL == "" {
 		return nil, fmt.Errorf("DATABASE_URL environment variable is not set")
 	}
 
-	pgxConfig, err := pgxpool.ParseConfig(databaseURL)
+	pgxConfig, err := pgxpool.ParseConfig(cfg.URL.Value())
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
 	}
 
-	pgxConfig.MinConns = int32(config.GetDatabaseMinConns())
-	pgxConfig.MaxConns = int32(config.GetDatabaseMaxConns())
-	pgxConfig.MaxConnLifetime = config.GetDatabaseMaxConnLifetime()
-	pgxConfig.MaxConnIdleTime = config.GetDatabaseMaxConnIdleTime()
-	pgxConfig.HealthCheckPeriod = config.GetDatabaseHealthCheckPeriod()
-	pgxConfig.ConnConfig.ConnectTimeout = config.GetDatabaseConnectTimeout()
+	pgxConfig.MinConns = int32(cfg.MinConns)
+	pgxConfig.MaxConns = int32(cfg.MaxConns)
+	pgxConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	pgxConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	pgxConfig.HealthCheckPeriod = cfg.HealthCheckPeriod
+	pgxConfig.ConnConfig.ConnectTimeout = cfg.ConnectTimeout
+	pgxConfig.ConnConfig.Tracer = otelpgx.NewTracer()
 
 	pool, err := pgxpool.NewWithConfig(ctx, pgxConfig)
 	if err != nil {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db/repository"
 )
 
@@ -13,11 +14,17 @@ type Database struct {
 	ConversationRepository *repository.ConversationRepository
 	MealLogRepository      *repository.MealLogRepository
 	NutritionRepository    *repository.NutritionSummaryRepository
+	ReportRepository       *repository.ReportRepository
+	SessionRepository      *repository.SessionRepository
+	IdempotencyRepository  *repository.IdempotencyRepository
+	JobRepository          *repository.JobRepository
+	UsageRepository        *repository.UsageRepository
+	ErasureRepository      *repository.ErasureRepository
 	pool                   *Pool
 }
 
-func NewDatabase(ctx context.Context) (*Database, error) {
-	pool, err := NewPool(ctx)
+func NewDatabase(ctx context.Context, cfg config.DatabaseConfig) (*Database, error) {
+	pool, err := NewPool(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
@@ -27,10 +34,21 @@ func NewDatabase(ctx context.Context) (*Database, error) {
 		ConversationRepository: repository.NewConversationRepository(pool.Queries),
 		MealLogRepository:      repository.NewMealLogRepository(pool.Queries),
 		NutritionRepository:    repository.NewNutritionSummaryRepository(pool.Queries),
+		ReportRepository:       repository.NewReportRepository(pool.Queries),
+		SessionRepository:      repository.NewSessionRepository(pool.Queries),
+		IdempotencyRepository:  repository.NewIdempotencyRepository(pool.Queries),
+		JobRepository:          repository.NewJobRepository(pool.Queries),
+		UsageRepository:        repository.NewUsageRepository(pool.Queries),
+		ErasureRepository:      repository.NewErasureRepository(pool.Queries),
 		pool:                   pool,
 	}, nil
 }
 
+// Ping checks that the database can be reached.
+func (d *Database) Ping(ctx context.Context) error {
+	return d.pool.Ping(ctx)
+}
+
 func (d *Database) Close() {
 	d.pool.Close()
 }
@@ -40,6 +58,12 @@ type TxDatabase struct {
 	ConversationRepository *repository.ConversationRepository
 	MealLogRepository      *repository.MealLogRepository
 	NutritionRepository    *repository.NutritionSummaryRepository
+	ReportRepository       *repository.ReportRepository
+	SessionRepository      *repository.SessionRepository
+	IdempotencyRepository  *repository.IdempotencyRepository
+	JobRepository          *repository.JobRepository
+	UsageRepository        *repository.UsageRepository
+	ErasureRepository      *repository.ErasureRepository
 	tx                     pgx.Tx
 }
 
@@ -60,6 +84,12 @@ func (d *Database) WithTx(ctx context.Context, fn func(ctx context.Context, txDB
 		ConversationRepository: repository.NewConversationRepository(d.pool.Queries.WithTx(tx)),
 		MealLogRepository:      repository.NewMealLogRepository(d.pool.Queries.WithTx(tx)),
 		NutritionRepository:    repository.NewNutritionSummaryRepository(d.pool.Queries.WithTx(tx)),
+		ReportRepository:       repository.NewReportRepository(d.pool.Queries.WithTx(tx)),
+		SessionRepository:      repository.NewSessionRepository(d.pool.Queries.WithTx(tx)),
+		IdempotencyRepository:  repository.NewIdempotencyRepository(d.pool.Queries.WithTx(tx)),
+		JobRepository:          repository.NewJobRepository(d.pool.Queries.WithTx(tx)),
+		UsageRepository:        repository.NewUsageRepository(d.pool.Queries.WithTx(tx)),
+		ErasureRepository:      repository.NewErasureRepository(d.pool.Queries.WithTx(tx)),
 		tx:                     tx,
 	}
 
@@ -0,0 +1,25 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Reports table
+CREATE TABLE reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    report_type VARCHAR(50) NOT NULL,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_reports_user_id ON reports(user_id);
+CREATE INDEX idx_reports_period_start ON reports(period_start);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS reports CASCADE;
+
+-- +migrate StatementEnd
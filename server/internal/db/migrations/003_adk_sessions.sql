@@ -0,0 +1,65 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- ADK sessions. User IDs are ADK user IDs, which are not always users rows;
+-- when they are, the session is linked to the conversation with the same session_id.
+CREATE TABLE adk_sessions (
+    app_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    id VARCHAR(255) NOT NULL,
+    conversation_id UUID REFERENCES conversations(id) ON DELETE CASCADE,
+    state JSONB NOT NULL DEFAULT '{}'::jsonb,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (app_name, user_id, id)
+);
+
+CREATE INDEX idx_adk_sessions_conversation_id ON adk_sessions(conversation_id);
+CREATE INDEX idx_adk_sessions_updated_at ON adk_sessions(updated_at);
+
+-- ADK session events, including their state deltas
+CREATE TABLE adk_events (
+    seq BIGSERIAL PRIMARY KEY,
+    id VARCHAR(255) NOT NULL,
+    app_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    session_id VARCHAR(255) NOT NULL,
+    invocation_id VARCHAR(255) NOT NULL DEFAULT '',
+    author VARCHAR(255) NOT NULL DEFAULT '',
+    event JSONB NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+
+    FOREIGN KEY (app_name, user_id, session_id) REFERENCES adk_sessions(app_name, user_id, id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_adk_events_session ON adk_events(app_name, user_id, session_id, timestamp);
+
+-- ADK app-scoped state ("app:" keys)
+CREATE TABLE adk_app_states (
+    app_name VARCHAR(255) PRIMARY KEY,
+    state JSONB NOT NULL DEFAULT '{}'::jsonb,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- ADK user-scoped state ("user:" keys)
+CREATE TABLE adk_user_states (
+    app_name VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    state JSONB NOT NULL DEFAULT '{}'::jsonb,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (app_name, user_id)
+);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS adk_user_states CASCADE;
+DROP TABLE IF EXISTS adk_app_states CASCADE;
+DROP TABLE IF EXISTS adk_events CASCADE;
+DROP TABLE IF EXISTS adk_sessions CASCADE;
+
+-- +migrate StatementEnd
@@ -0,0 +1,27 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Idempotency keys sent by clients, with the hash of the request they were
+-- first used for and the response to replay. response is NULL while the
+-- request is being processed.
+CREATE TABLE idempotency_keys (
+    operation VARCHAR(100) NOT NULL,
+    key VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    response JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+
+    PRIMARY KEY (operation, key)
+);
+
+CREATE INDEX idx_idempotency_keys_created_at ON idempotency_keys(created_at);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS idempotency_keys CASCADE;
+
+-- +migrate StatementEnd
@@ -0,0 +1,34 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Background jobs, claimed by workers with FOR UPDATE SKIP LOCKED
+CREATE TABLE jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind VARCHAR(50) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    payload JSONB NOT NULL,
+    result JSONB,
+    error TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL DEFAULT 3,
+    callback_url TEXT,
+    run_after TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_jobs_queue ON jobs(run_after) WHERE status = 'queued';
+CREATE INDEX idx_jobs_running ON jobs(locked_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_user_id ON jobs(user_id);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS jobs CASCADE;
+
+-- +migrate StatementEnd
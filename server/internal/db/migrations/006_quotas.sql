@@ -0,0 +1,28 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- The rate limit plan of a user; NULL means the configured default plan.
+ALTER TABLE users ADD COLUMN plan VARCHAR(50);
+
+-- Agent invocations per user and UTC day, counted against the daily quota of
+-- the user's plan. user_id is the ID sent with agent requests, which need not
+-- be a registered user.
+CREATE TABLE agent_usage (
+    user_id VARCHAR(255) NOT NULL,
+    day DATE NOT NULL,
+    invocations INTEGER NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (user_id, day)
+);
+
+CREATE INDEX idx_agent_usage_day ON agent_usage(day);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS agent_usage CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS plan;
+
+-- +migrate StatementEnd
@@ -0,0 +1,34 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Tokens of every LLM call agents make, with the cost estimated from the
+-- configured model prices at the time of the call. NULL estimated_cost_usd
+-- means the model had no configured price. Calls that produced a meal are
+-- linked to it.
+CREATE TABLE llm_usage (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    app_name VARCHAR(255) NOT NULL,
+    session_id VARCHAR(255) NOT NULL,
+    invocation_id VARCHAR(255) NOT NULL,
+    model VARCHAR(255) NOT NULL,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    candidates_tokens INTEGER NOT NULL DEFAULT 0,
+    total_tokens INTEGER NOT NULL DEFAULT 0,
+    estimated_cost_usd DOUBLE PRECISION,
+    meal_log_id UUID REFERENCES meal_logs(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_llm_usage_user_created_at ON llm_usage(user_id, created_at);
+CREATE INDEX idx_llm_usage_invocation_id ON llm_usage(invocation_id);
+CREATE INDEX idx_llm_usage_meal_log_id ON llm_usage(meal_log_id);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS llm_usage CASCADE;
+
+-- +migrate StatementEnd
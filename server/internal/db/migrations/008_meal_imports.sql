@@ -0,0 +1,21 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Meal logs imported from other trackers remember where they came from.
+-- import_key identifies the source row, so importing the same file again
+-- skips the rows already imported. Logs created in the app have neither.
+ALTER TABLE meal_logs ADD COLUMN import_source VARCHAR(50);
+ALTER TABLE meal_logs ADD COLUMN import_key VARCHAR(64);
+
+CREATE UNIQUE INDEX idx_meal_logs_import_key ON meal_logs(user_id, import_source, import_key);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP INDEX IF EXISTS idx_meal_logs_import_key;
+ALTER TABLE meal_logs DROP COLUMN IF EXISTS import_key;
+ALTER TABLE meal_logs DROP COLUMN IF EXISTS import_source;
+
+-- +migrate StatementEnd
@@ -0,0 +1,22 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Tombstones of erased users: what was removed and when, kept after every
+-- row of the user is gone. They hold no personal data beyond the user ID.
+CREATE TABLE user_erasures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL UNIQUE,
+    job_id UUID,
+    erased JSONB NOT NULL DEFAULT '{}'::jsonb,
+    adk_sessions_deleted INTEGER NOT NULL DEFAULT 0,
+    erased_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS user_erasures CASCADE;
+
+-- +migrate StatementEnd
@@ -0,0 +1,37 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- The searchable text of a meal's assumptions: the category, field and
+-- rationale of each one.
+CREATE FUNCTION meal_assumption_text(assumptions JSONB) RETURNS TEXT
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT COALESCE(string_agg(concat_ws(' ', a->>'category', a->>'field', a->>'rationale'), ' '), '')
+    FROM jsonb_array_elements(CASE WHEN jsonb_typeof(assumptions) = 'array' THEN assumptions ELSE '[]'::jsonb END) AS a
+$$;
+
+-- The full-text document of a meal, food names weighing more than
+-- assumptions. Searches must use the same expression to use the index.
+CREATE FUNCTION meal_search_document(food_name TEXT, assumptions JSONB) RETURNS TSVECTOR
+LANGUAGE sql IMMUTABLE PARALLEL SAFE AS $$
+    SELECT setweight(to_tsvector('english', food_name), 'A')
+        || setweight(to_tsvector('english', meal_assumption_text(assumptions)), 'B')
+$$;
+
+CREATE INDEX idx_meal_logs_search_document ON meal_logs USING GIN (meal_search_document(food_name, assumptions));
+CREATE INDEX idx_meal_logs_food_name_trgm ON meal_logs USING GIN (food_name gin_trgm_ops);
+CREATE INDEX idx_meal_logs_assumption_text_trgm ON meal_logs USING GIN (meal_assumption_text(assumptions) gin_trgm_ops);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP INDEX IF EXISTS idx_meal_logs_assumption_text_trgm;
+DROP INDEX IF EXISTS idx_meal_logs_food_name_trgm;
+DROP INDEX IF EXISTS idx_meal_logs_search_document;
+DROP FUNCTION IF EXISTS meal_search_document(TEXT, JSONB);
+DROP FUNCTION IF EXISTS meal_assumption_text(JSONB);
+
+-- +migrate StatementEnd
@@ -0,0 +1,30 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Edits of a meal log made without the agent, such as scaling its portion.
+-- Each revision keeps the macros and assumptions from before and after the
+-- edit, so a meal's history can be shown and undone.
+CREATE TABLE meal_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    meal_log_id UUID NOT NULL REFERENCES meal_logs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(50) NOT NULL,
+    factor DOUBLE PRECISION NOT NULL,
+    previous_macros JSONB NOT NULL,
+    previous_assumptions JSONB NOT NULL,
+    macros JSONB NOT NULL,
+    assumptions JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_meal_revisions_meal_log_id ON meal_revisions(meal_log_id, created_at);
+CREATE INDEX idx_meal_revisions_user_id ON meal_revisions(user_id);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DROP TABLE IF EXISTS meal_revisions CASCADE;
+
+-- +migrate StatementEnd
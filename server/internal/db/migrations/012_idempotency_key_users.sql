@@ -0,0 +1,22 @@
+-- +migrate Up
+-- +migrate StatementBegin
+
+-- Idempotency keys belong to the user who sent them, so two users sending the
+-- same key neither see each other's responses nor collide. Keys stored
+-- before had no user and are dropped; they only lived for the key TTL.
+DELETE FROM idempotency_keys;
+ALTER TABLE idempotency_keys ADD COLUMN user_id VARCHAR(255) NOT NULL;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (operation, user_id, key);
+
+-- +migrate StatementEnd
+
+-- +migrate Down
+-- +migrate StatementBegin
+
+DELETE FROM idempotency_keys;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS user_id;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (operation, key);
+
+-- +migrate StatementEnd
@@ -0,0 +1,60 @@
+-- name: CreateADKSession :one
+INSERT INTO adk_sessions (app_name, user_id, id, conversation_id, state)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetADKSession :one
+SELECT * FROM adk_sessions WHERE app_name = $1 AND user_id = $2 AND id = $3;
+
+-- name: ListADKSessionsByUser :many
+SELECT * FROM adk_sessions WHERE app_name = $1 AND user_id = $2 ORDER BY updated_at DESC;
+
+-- name: ListADKSessionsOfUser :many
+-- Sessions of a user across every app.
+SELECT * FROM adk_sessions WHERE user_id = $1 ORDER BY created_at ASC, app_name ASC, id ASC;
+
+-- name: ListADKSessionsByApp :many
+SELECT * FROM adk_sessions WHERE app_name = $1 ORDER BY updated_at DESC;
+
+-- name: MergeADKSessionState :exec
+UPDATE adk_sessions
+SET state = state || sqlc.arg(delta)::jsonb, updated_at = sqlc.arg(updated_at)
+WHERE app_name = sqlc.arg(app_name) AND user_id = sqlc.arg(user_id) AND id = sqlc.arg(id);
+
+-- name: DeleteADKSession :exec
+DELETE FROM adk_sessions WHERE app_name = $1 AND user_id = $2 AND id = $3;
+
+-- name: LinkSessionConversation :one
+INSERT INTO conversations (user_id, session_id)
+SELECT u.id, sqlc.arg(session_id) FROM users u WHERE u.id = sqlc.arg(user_id)
+ON CONFLICT (user_id, session_id) DO UPDATE SET updated_at = NOW()
+RETURNING conversations.id;
+
+-- name: CreateADKEvent :exec
+INSERT INTO adk_events (id, app_name, user_id, session_id, invocation_id, author, event, timestamp)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: ListADKEvents :many
+SELECT * FROM adk_events
+WHERE app_name = $1 AND user_id = $2 AND session_id = $3
+ORDER BY timestamp ASC, seq ASC;
+
+-- name: GetADKAppState :one
+SELECT state FROM adk_app_states WHERE app_name = $1;
+
+-- name: MergeADKAppState :one
+INSERT INTO adk_app_states (app_name, state)
+VALUES ($1, $2)
+ON CONFLICT (app_name) DO UPDATE
+SET state = adk_app_states.state || EXCLUDED.state, updated_at = NOW()
+RETURNING state;
+
+-- name: GetADKUserState :one
+SELECT state FROM adk_user_states WHERE app_name = $1 AND user_id = $2;
+
+-- name: MergeADKUserState :one
+INSERT INTO adk_user_states (app_name, user_id, state)
+VALUES ($1, $2, $3)
+ON CONFLICT (app_name, user_id) DO UPDATE
+SET state = adk_user_states.state || EXCLUDED.state, updated_at = NOW()
+RETURNING state;
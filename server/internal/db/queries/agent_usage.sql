@@ -0,0 +1,9 @@
+-- name: ConsumeAgentInvocation :one
+-- Counts an invocation unless the day's count already reached daily_limit, in
+-- which case no row is returned.
+INSERT INTO agent_usage (user_id, day, invocations)
+VALUES (sqlc.arg(user_id), sqlc.arg(day), 1)
+ON CONFLICT (user_id, day) DO UPDATE
+SET invocations = agent_usage.invocations + 1
+WHERE agent_usage.invocations < sqlc.arg(daily_limit)::integer
+RETURNING invocations;
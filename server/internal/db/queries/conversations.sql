@@ -20,3 +20,10 @@ RETURNING *;
 
 -- name: DeleteConversation :exec
 DELETE FROM conversations WHERE id = $1;
+
+-- name: SetConversationTitleIfEmpty :one
+-- Titles a conversation unless it already has a title, e.g. one the user set.
+UPDATE conversations
+SET title = $2, updated_at = NOW()
+WHERE id = $1 AND title IS NULL
+RETURNING *;
@@ -28,3 +28,29 @@ WHERE user_id = $1
 AND date >= $2 
 AND date <= $3
 ORDER BY date ASC;
+
+-- name: RebuildDailyNutritionSummary :one
+-- Recomputes the summary of a UTC day from the meal logs.
+INSERT INTO daily_nutrition_summaries (
+    user_id, date, total_calories, total_protein, total_carbs, total_fat, meal_count
+)
+SELECT
+    sqlc.arg(user_id)::uuid,
+    sqlc.arg(date)::date,
+    COALESCE(SUM((macros->>'calories')::numeric), 0),
+    COALESCE(SUM((macros->>'protein')::numeric), 0),
+    COALESCE(SUM((macros->>'carbs')::numeric), 0),
+    COALESCE(SUM((macros->>'fat')::numeric), 0),
+    COUNT(*)
+FROM meal_logs
+WHERE user_id = sqlc.arg(user_id)::uuid
+AND (recorded_at AT TIME ZONE 'UTC')::date = sqlc.arg(date)::date
+ON CONFLICT (user_id, date)
+DO UPDATE SET
+    total_calories = EXCLUDED.total_calories,
+    total_protein = EXCLUDED.total_protein,
+    total_carbs = EXCLUDED.total_carbs,
+    total_fat = EXCLUDED.total_fat,
+    meal_count = EXCLUDED.meal_count,
+    updated_at = NOW()
+RETURNING *;
@@ -0,0 +1,20 @@
+-- name: ClaimIdempotencyKey :one
+-- Claims a key for a new request. An expired key is taken over; a live one is
+-- left untouched and no row is returned.
+INSERT INTO idempotency_keys (operation, user_id, key, request_hash)
+VALUES (sqlc.arg(operation), sqlc.arg(user_id), sqlc.arg(key), sqlc.arg(request_hash))
+ON CONFLICT (operation, user_id, key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash, response = NULL, created_at = NOW(), completed_at = NULL
+WHERE idempotency_keys.created_at < sqlc.arg(expired_before)
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys WHERE operation = $1 AND user_id = $2 AND key = $3;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET response = $4, completed_at = NOW()
+WHERE operation = $1 AND user_id = $2 AND key = $3;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys WHERE operation = $1 AND user_id = $2 AND key = $3;
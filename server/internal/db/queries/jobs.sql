@@ -0,0 +1,48 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (kind, user_id, payload, max_attempts, callback_url)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetJob :one
+SELECT * FROM jobs WHERE id = $1;
+
+-- name: ClaimNextJob :one
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, locked_at = NOW(), updated_at = NOW()
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE status = 'queued' AND run_after <= NOW()
+    ORDER BY run_after ASC
+    FOR UPDATE SKIP LOCKED
+    LIMIT 1
+)
+RETURNING *;
+
+-- name: CompleteJob :one
+UPDATE jobs
+SET status = 'succeeded', result = $2, error = NULL, locked_at = NULL, updated_at = NOW(), completed_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: FailJob :one
+-- Puts the job back in the queue until it runs out of attempts.
+UPDATE jobs
+SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'queued' END,
+    error = sqlc.arg(error),
+    run_after = sqlc.arg(retry_at),
+    locked_at = NULL,
+    updated_at = NOW(),
+    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- name: RequeueStaleJobs :execrows
+-- Returns jobs of workers that died mid-run to the queue, or fails them if
+-- they ran out of attempts.
+UPDATE jobs
+SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'queued' END,
+    error = 'worker stopped while running the job',
+    locked_at = NULL,
+    updated_at = NOW(),
+    completed_at = CASE WHEN attempts >= max_attempts THEN NOW() ELSE NULL END
+WHERE status = 'running' AND locked_at < $1;
@@ -0,0 +1,32 @@
+-- name: RecordLLMUsage :exec
+INSERT INTO llm_usage (
+    user_id, app_name, session_id, invocation_id, model,
+    prompt_tokens, candidates_tokens, total_tokens, estimated_cost_usd
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: LinkLLMUsageToMeal :exec
+UPDATE llm_usage SET meal_log_id = $2 WHERE invocation_id = $1;
+
+-- name: ListLLMUsageByUser :many
+SELECT * FROM llm_usage
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC
+LIMIT $2 OFFSET $3;
+
+-- name: ListDailyLLMUsageByUser :many
+-- Daily totals of a user in UTC days, from start_date to end_date inclusive.
+SELECT
+    (created_at AT TIME ZONE 'UTC')::date AS day,
+    COUNT(DISTINCT invocation_id)::integer AS invocations,
+    COUNT(*)::integer AS llm_calls,
+    COALESCE(SUM(prompt_tokens), 0)::bigint AS prompt_tokens,
+    COALESCE(SUM(candidates_tokens), 0)::bigint AS candidates_tokens,
+    COALESCE(SUM(total_tokens), 0)::bigint AS total_tokens,
+    COALESCE(SUM(estimated_cost_usd), 0)::double precision AS estimated_cost_usd,
+    COUNT(*) FILTER (WHERE estimated_cost_usd IS NULL)::integer AS unpriced_calls
+FROM llm_usage
+WHERE user_id = sqlc.arg(user_id)
+AND (created_at AT TIME ZONE 'UTC')::date BETWEEN sqlc.arg(start_date)::date AND sqlc.arg(end_date)::date
+GROUP BY day
+ORDER BY day ASC;
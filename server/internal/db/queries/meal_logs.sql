@@ -36,3 +36,107 @@ WHERE user_id = $1 AND recorded_at::date = $2;
 
 -- name: DeleteMealLog :exec
 DELETE FROM meal_logs WHERE id = $1;
+
+-- name: ListMealLogsByUserAfter :many
+-- Pages through a user's meal logs, oldest first. The first page has no
+-- after_recorded_at; later ones pass the recorded_at and id of the last row.
+SELECT * FROM meal_logs
+WHERE user_id = sqlc.arg(user_id)
+AND (
+    sqlc.narg(after_recorded_at)::timestamptz IS NULL
+    OR (recorded_at, id) > (sqlc.narg(after_recorded_at)::timestamptz, sqlc.narg(after_id)::uuid)
+)
+ORDER BY recorded_at ASC, id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: ImportMealLog :one
+-- Returns no row when the user already imported the row with this key.
+INSERT INTO meal_logs (
+    user_id, food_name, meal_type, recorded_at, macros, assumptions,
+    food_source, import_source, import_key
+)
+VALUES ($1, $2, $3, $4, $5, '[]'::jsonb, 'manual_entry', $6, $7)
+ON CONFLICT (user_id, import_source, import_key) DO NOTHING
+RETURNING *;
+
+-- name: SearchMealLogs :many
+-- Ranks a user's meals by full-text match on the food name and assumptions
+-- plus trigram similarity, so misspelled and partial words still match.
+-- Pages continue after the rank and id of the last row of the previous one.
+WITH matches AS (
+    SELECT m.*,
+        (ts_rank(meal_search_document(m.food_name, m.assumptions), websearch_to_tsquery('english', sqlc.arg(query)::text))
+            + GREATEST(
+                word_similarity(sqlc.arg(query)::text, m.food_name),
+                word_similarity(sqlc.arg(query)::text, meal_assumption_text(m.assumptions)) / 2
+            ))::float8 AS rank
+    FROM meal_logs m
+    WHERE m.user_id = sqlc.arg(user_id)
+    AND (
+        meal_search_document(m.food_name, m.assumptions) @@ websearch_to_tsquery('english', sqlc.arg(query)::text)
+        OR sqlc.arg(query)::text <% m.food_name
+        OR sqlc.arg(query)::text <% meal_assumption_text(m.assumptions)
+    )
+    AND (sqlc.narg(meal_type)::text IS NULL OR m.meal_type::text = sqlc.narg(meal_type)::text)
+    AND (sqlc.narg(food_source)::text IS NULL OR m.food_source::text = sqlc.narg(food_source)::text)
+    AND (sqlc.narg(recorded_from)::timestamptz IS NULL OR m.recorded_at >= sqlc.narg(recorded_from)::timestamptz)
+    AND (sqlc.narg(recorded_before)::timestamptz IS NULL OR m.recorded_at < sqlc.narg(recorded_before)::timestamptz)
+    AND (sqlc.narg(min_calories)::float8 IS NULL OR (m.macros->>'calories')::float8 >= sqlc.narg(min_calories)::float8)
+    AND (sqlc.narg(max_calories)::float8 IS NULL OR (m.macros->>'calories')::float8 <= sqlc.narg(max_calories)::float8)
+)
+SELECT * FROM matches
+WHERE sqlc.narg(after_rank)::float8 IS NULL
+    OR rank < sqlc.narg(after_rank)::float8
+    OR (rank = sqlc.narg(after_rank)::float8 AND id > sqlc.narg(after_id)::uuid)
+ORDER BY rank DESC, id ASC
+LIMIT sqlc.arg(page_size);
+
+-- name: ListFrequentMealsByUser :many
+-- Ranks the foods a user logged since recorded_from by how often, matching
+-- names case-insensitively. MyFitnessPal imports are meal totals rather
+-- than foods, so they are left out.
+SELECT
+    (array_agg(id ORDER BY recorded_at DESC))[1]::uuid AS latest_meal_id,
+    (array_agg(food_name ORDER BY recorded_at DESC))[1]::text AS food_name,
+    (mode() WITHIN GROUP (ORDER BY meal_type))::text AS usual_meal_type,
+    COUNT(*)::integer AS times_logged,
+    MAX(recorded_at)::timestamptz AS last_logged_at,
+    AVG((macros->>'calories')::float8)::float8 AS avg_calories,
+    AVG((macros->>'protein')::float8)::float8 AS avg_protein,
+    AVG((macros->>'carbs')::float8)::float8 AS avg_carbs,
+    AVG((macros->>'fat')::float8)::float8 AS avg_fat
+FROM meal_logs
+WHERE user_id = sqlc.arg(user_id)
+AND recorded_at >= sqlc.arg(recorded_from)
+AND import_source IS DISTINCT FROM 'myfitnesspal'
+GROUP BY lower(btrim(food_name))
+ORDER BY times_logged DESC, last_logged_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetMealLogForUpdate :one
+-- Locks the meal log until the end of the transaction, so concurrent edits
+-- apply one after the other.
+SELECT * FROM meal_logs WHERE id = $1 FOR UPDATE;
+
+-- name: UpdateMealLogPortion :one
+UPDATE meal_logs
+SET macros = $2, assumptions = $3
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateMealRevision :one
+INSERT INTO meal_revisions (
+    meal_log_id, user_id, kind, factor, previous_macros,
+    previous_assumptions, macros, assumptions
+)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: ListMealRevisions :many
+SELECT * FROM meal_revisions WHERE meal_log_id = $1 ORDER BY created_at ASC, id ASC;
+
+-- name: ListMealRevisionsByUser :many
+SELECT * FROM meal_revisions
+WHERE user_id = $1
+ORDER BY created_at ASC, id ASC
+LIMIT $2 OFFSET $3;
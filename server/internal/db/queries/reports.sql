@@ -0,0 +1,13 @@
+-- name: CreateReport :one
+INSERT INTO reports (user_id, report_type, period_start, period_end, content)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetReport :one
+SELECT * FROM reports WHERE id = $1;
+
+-- name: ListReportsByUser :many
+SELECT * FROM reports
+WHERE user_id = $1
+ORDER BY period_start DESC, created_at DESC
+LIMIT $2 OFFSET $3;
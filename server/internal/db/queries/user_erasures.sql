@@ -0,0 +1,51 @@
+-- Erasing a user deletes the rows that reference the user one table at a
+-- time, to count them, before deleting the user itself. Tables keyed by ADK
+-- user IDs have no foreign key to users and would not cascade.
+
+-- name: EraseUserIdempotencyKeys :execrows
+DELETE FROM idempotency_keys WHERE user_id = $1;
+
+-- name: EraseUserMealRevisions :execrows
+DELETE FROM meal_revisions WHERE user_id = $1;
+
+-- name: EraseUserMealLogs :execrows
+DELETE FROM meal_logs WHERE user_id = $1;
+
+-- name: EraseUserConversations :execrows
+DELETE FROM conversations WHERE user_id = $1;
+
+-- name: EraseUserReports :execrows
+DELETE FROM reports WHERE user_id = $1;
+
+-- name: EraseUserDailySummaries :execrows
+DELETE FROM daily_nutrition_summaries WHERE user_id = $1;
+
+-- name: EraseUserWeeklySummaries :execrows
+DELETE FROM weekly_nutrition_summaries WHERE user_id = $1;
+
+-- name: EraseUserADKSessions :execrows
+DELETE FROM adk_sessions WHERE user_id = $1;
+
+-- name: EraseUserADKStates :execrows
+DELETE FROM adk_user_states WHERE user_id = $1;
+
+-- name: EraseUserAgentUsage :execrows
+DELETE FROM agent_usage WHERE user_id = $1;
+
+-- name: EraseUserLLMUsage :execrows
+DELETE FROM llm_usage WHERE user_id = $1;
+
+-- name: EraseUserJobs :execrows
+-- Keeps the erasure job itself, which reports the outcome.
+DELETE FROM jobs WHERE user_id = sqlc.arg(user_id) AND id <> sqlc.arg(keep_job_id);
+
+-- name: EraseUser :execrows
+DELETE FROM users WHERE id = $1;
+
+-- name: CreateUserErasure :one
+INSERT INTO user_erasures (user_id, job_id, erased, adk_sessions_deleted)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetUserErasure :one
+SELECT * FROM user_erasures WHERE user_id = $1;
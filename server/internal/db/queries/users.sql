@@ -14,3 +14,18 @@ SELECT * FROM users ORDER BY created_at DESC;
 
 -- name: UserExists :one
 SELECT EXISTS(SELECT 1 FROM users WHERE username = $1) AS exists;
+
+-- name: UpdateUserMetadata :one
+UPDATE users
+SET metadata = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserPlan :one
+SELECT plan FROM users WHERE id = $1;
+
+-- name: UpdateUserPlan :one
+UPDATE users
+SET plan = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
@@ -33,3 +33,40 @@ WHERE user_id = $1
 AND week_start_date >= $2 
 AND week_start_date <= $3
 ORDER BY week_start_date ASC;
+
+-- name: RebuildWeeklyNutritionSummary :one
+-- Recomputes the summary of the week starting on week_start_date from the
+-- meal logs. Daily averages are over the days with at least one meal.
+INSERT INTO weekly_nutrition_summaries (
+    user_id, week_start_date, total_calories, total_protein, total_carbs, total_fat,
+    avg_daily_calories, avg_daily_protein, avg_daily_carbs, avg_daily_fat, meal_count
+)
+SELECT
+    sqlc.arg(user_id)::uuid,
+    sqlc.arg(week_start_date)::date,
+    COALESCE(SUM((macros->>'calories')::numeric), 0),
+    COALESCE(SUM((macros->>'protein')::numeric), 0),
+    COALESCE(SUM((macros->>'carbs')::numeric), 0),
+    COALESCE(SUM((macros->>'fat')::numeric), 0),
+    COALESCE(SUM((macros->>'calories')::numeric) / NULLIF(COUNT(DISTINCT (recorded_at AT TIME ZONE 'UTC')::date), 0), 0),
+    COALESCE(SUM((macros->>'protein')::numeric) / NULLIF(COUNT(DISTINCT (recorded_at AT TIME ZONE 'UTC')::date), 0), 0),
+    COALESCE(SUM((macros->>'carbs')::numeric) / NULLIF(COUNT(DISTINCT (recorded_at AT TIME ZONE 'UTC')::date), 0), 0),
+    COALESCE(SUM((macros->>'fat')::numeric) / NULLIF(COUNT(DISTINCT (recorded_at AT TIME ZONE 'UTC')::date), 0), 0),
+    COUNT(*)
+FROM meal_logs
+WHERE user_id = sqlc.arg(user_id)::uuid
+AND (recorded_at AT TIME ZONE 'UTC')::date >= sqlc.arg(week_start_date)::date
+AND (recorded_at AT TIME ZONE 'UTC')::date < sqlc.arg(week_start_date)::date + 7
+ON CONFLICT (user_id, week_start_date)
+DO UPDATE SET
+    total_calories = EXCLUDED.total_calories,
+    total_protein = EXCLUDED.total_protein,
+    total_carbs = EXCLUDED.total_carbs,
+    total_fat = EXCLUDED.total_fat,
+    avg_daily_calories = EXCLUDED.avg_daily_calories,
+    avg_daily_protein = EXCLUDED.avg_daily_protein,
+    avg_daily_carbs = EXCLUDED.avg_daily_carbs,
+    avg_daily_fat = EXCLUDED.avg_daily_fat,
+    meal_count = EXCLUDED.meal_count,
+    updated_at = NOW()
+RETURNING *;
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
 	"github.com/simhozebs/mugo/internal/models"
@@ -118,6 +121,31 @@ func (r *ConversationRepository) UpdateTitle(ctx context.Context, id, title stri
 	return mapToConversation(result), nil
 }
 
+// SetTitleIfUntitled sets the title of a conversation that has none. It
+// returns nil if the conversation already has a title or does not exist.
+func (r *ConversationRepository) SetTitleIfUntitled(ctx context.Context, id, title string) (*models.Conversation, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.SetConversationTitleIfEmptyParams{
+		ID:    pgUUID,
+		Title: pgtype.Text{String: title, Valid: true},
+	}
+	result, err := r.queries.SetConversationTitleIfEmpty(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to set conversation title: %w", err)
+	}
+	return mapToConversation(result), nil
+}
+
 func (r *ConversationRepository) Delete(ctx context.Context, id string) error {
 	parsedUUID, err := uuid.Parse(id)
 	if err != nil {
@@ -130,6 +158,42 @@ func (r *ConversationRepository) Delete(ctx context.Context, id string) error {
 	return r.queries.DeleteConversation(ctx, pgUUID)
 }
 
+// ListMessages returns the messages of a conversation, oldest first.
+func (r *ConversationRepository) ListMessages(ctx context.Context, conversationID string) ([]*models.ConversationMessage, error) {
+	parsedUUID, err := uuid.Parse(conversationID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid conversation UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	results, err := r.queries.ListMessagesByConversation(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	messages := make([]*models.ConversationMessage, len(results))
+	for i, m := range results {
+		messages[i] = mapToConversationMessage(m)
+	}
+	return messages, nil
+}
+
+func mapToConversationMessage(m dbgenerated.ConversationMessage) *models.ConversationMessage {
+	var metadata map[string]interface{}
+	if m.Metadata != nil {
+		json.Unmarshal(m.Metadata, &metadata)
+	}
+	return &models.ConversationMessage{
+		ID:             m.ID.String(),
+		ConversationID: m.ConversationID.String(),
+		Role:           m.Role.(string),
+		Content:        m.Content,
+		Metadata:       metadata,
+		CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
+	}
+}
+
 func mapToConversation(c dbgenerated.Conversation) *models.Conversation {
 	return &models.Conversation{
 		ID:        c.ID.String(),
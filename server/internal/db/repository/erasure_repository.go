@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+type ErasureRepository struct {
+	queries *dbgenerated.Queries
+}
+
+func NewErasureRepository(queries *dbgenerated.Queries) *ErasureRepository {
+	return &ErasureRepository{queries: queries}
+}
+
+// Erase deletes every row of the user, the user itself included, and records
+// the tombstone. It should run in a transaction, so a failure erases nothing.
+// jobID is the erasure job, which is kept; adkSessionsDeleted is recorded as
+// is. It returns nil if the user does not exist.
+func (r *ErasureRepository) Erase(ctx context.Context, userID, jobID string, adkSessionsDeleted int) (*models.UserErasure, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	var pgJobUUID pgtype.UUID
+	if jobID != "" {
+		parsedJobUUID, err := uuid.Parse(jobID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid job UUID: %w", err)
+		}
+		pgJobUUID = pgtype.UUID{
+			Bytes: [16]byte(parsedJobUUID),
+			Valid: true,
+		}
+	}
+
+	// Children first, so each table's count is its own.
+	steps := []struct {
+		table string
+		erase func() (int64, error)
+	}{
+		{"meal_revisions", func() (int64, error) { return r.queries.EraseUserMealRevisions(ctx, pgUUID) }},
+		{"meal_logs", func() (int64, error) { return r.queries.EraseUserMealLogs(ctx, pgUUID) }},
+		{"conversations", func() (int64, error) { return r.queries.EraseUserConversations(ctx, pgUUID) }},
+		{"reports", func() (int64, error) { return r.queries.EraseUserReports(ctx, pgUUID) }},
+		{"daily_nutrition_summaries", func() (int64, error) { return r.queries.EraseUserDailySummaries(ctx, pgUUID) }},
+		{"weekly_nutrition_summaries", func() (int64, error) { return r.queries.EraseUserWeeklySummaries(ctx, pgUUID) }},
+		{"adk_sessions", func() (int64, error) { return r.queries.EraseUserADKSessions(ctx, userID) }},
+		{"adk_user_states", func() (int64, error) { return r.queries.EraseUserADKStates(ctx, userID) }},
+		{"agent_usage", func() (int64, error) { return r.queries.EraseUserAgentUsage(ctx, userID) }},
+		{"llm_usage", func() (int64, error) { return r.queries.EraseUserLLMUsage(ctx, userID) }},
+		{"idempotency_keys", func() (int64, error) { return r.queries.EraseUserIdempotencyKeys(ctx, userID) }},
+		{"jobs", func() (int64, error) {
+			return r.queries.EraseUserJobs(ctx, dbgenerated.EraseUserJobsParams{UserID: userID, KeepJobID: pgJobUUID})
+		}},
+	}
+	erased := make(map[string]int64, len(steps)+1)
+	for _, step := range steps {
+		n, err := step.erase()
+		if err != nil {
+			return nil, fmt.Errorf("failed to erase %s: %w", step.table, err)
+		}
+		erased[step.table] = n
+	}
+
+	n, err := r.queries.EraseUser(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase user: %w", err)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	erased["users"] = n
+
+	erasedJSON, err := json.Marshal(erased)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal erased counts: %w", err)
+	}
+	arg := dbgenerated.CreateUserErasureParams{
+		UserID:             pgUUID,
+		JobID:              pgJobUUID,
+		Erased:             erasedJSON,
+		AdkSessionsDeleted: int32(adkSessionsDeleted),
+	}
+	result, err := r.queries.CreateUserErasure(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record user erasure: %w", err)
+	}
+	return mapToUserErasure(result), nil
+}
+
+// GetByUser returns the tombstone of an erased user, or nil if the user was
+// not erased.
+func (r *ErasureRepository) GetByUser(ctx context.Context, userID string) (*models.UserErasure, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	result, err := r.queries.GetUserErasure(ctx, pgUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get user erasure: %w", err)
+	}
+	return mapToUserErasure(result), nil
+}
+
+func mapToUserErasure(e dbgenerated.UserErasure) *models.UserErasure {
+	var erased map[string]int64
+	if e.Erased != nil {
+		json.Unmarshal(e.Erased, &erased)
+	}
+
+	var jobID *string
+	if e.JobID.Valid {
+		s := e.JobID.String()
+		jobID = &s
+	}
+
+	return &models.UserErasure{
+		ID:                 e.ID.String(),
+		UserID:             e.UserID.String(),
+		JobID:              jobID,
+		Erased:             erased,
+		ADKSessionsDeleted: int(e.AdkSessionsDeleted),
+		ErasedAt:           e.ErasedAt.Time.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+type IdempotencyRepository struct {
+	queries *dbgenerated.Queries
+}
+
+func NewIdempotencyRepository(queries *dbgenerated.Queries) *IdempotencyRepository {
+	return &IdempotencyRepository{queries: queries}
+}
+
+// Claim records the key of a user for a new request and reports whether it
+// was free. A key created before expiredBefore counts as free and is taken
+// over.
+func (r *IdempotencyRepository) Claim(ctx context.Context, operation, userID, key, requestHash string, expiredBefore time.Time) (bool, error) {
+	arg := dbgenerated.ClaimIdempotencyKeyParams{
+		Operation:     operation,
+		UserID:        userID,
+		Key:           key,
+		RequestHash:   requestHash,
+		ExpiredBefore: pgtype.Timestamptz{Time: expiredBefore, Valid: true},
+	}
+	_, err := r.queries.ClaimIdempotencyKey(ctx, arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	return true, nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, operation, userID, key string) (*models.IdempotencyKey, error) {
+	arg := dbgenerated.GetIdempotencyKeyParams{
+		Operation: operation,
+		UserID:    userID,
+		Key:       key,
+	}
+	result, err := r.queries.GetIdempotencyKey(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return mapToIdempotencyKey(result), nil
+}
+
+// Complete stores the response to replay for the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, operation, userID, key string, response []byte) error {
+	arg := dbgenerated.CompleteIdempotencyKeyParams{
+		Operation: operation,
+		UserID:    userID,
+		Key:       key,
+		Response:  response,
+	}
+	if err := r.queries.CompleteIdempotencyKey(ctx, arg); err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release frees the key so that the request can be tried again.
+func (r *IdempotencyRepository) Release(ctx context.Context, operation, userID, key string) error {
+	arg := dbgenerated.DeleteIdempotencyKeyParams{
+		Operation: operation,
+		UserID:    userID,
+		Key:       key,
+	}
+	if err := r.queries.DeleteIdempotencyKey(ctx, arg); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func mapToIdempotencyKey(k dbgenerated.IdempotencyKey) *models.IdempotencyKey {
+	var completedAt *time.Time
+	if k.CompletedAt.Valid {
+		completedAt = &k.CompletedAt.Time
+	}
+
+	return &models.IdempotencyKey{
+		Operation:   k.Operation,
+		UserID:      k.UserID,
+		Key:         k.Key,
+		RequestHash: k.RequestHash,
+		Response:    k.Response,
+		CreatedAt:   k.CreatedAt.Time,
+		CompletedAt: completedAt,
+	}
+}
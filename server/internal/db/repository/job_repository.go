@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+type JobRepository struct {
+	queries *dbgenerated.Queries
+}
+
+func NewJobRepository(queries *dbgenerated.Queries) *JobRepository {
+	return &JobRepository{queries: queries}
+}
+
+func (r *JobRepository) Enqueue(ctx context.Context, kind models.JobKind, userID string, payload any, maxAttempts int, callbackURL string) (*models.Job, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job payload: %w", err)
+	}
+	arg := dbgenerated.EnqueueJobParams{
+		Kind:        string(kind),
+		UserID:      userID,
+		Payload:     payloadJSON,
+		MaxAttempts: int32(maxAttempts),
+		CallbackUrl: pgtype.Text{String: callbackURL, Valid: callbackURL != ""},
+	}
+	result, err := r.queries.EnqueueJob(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+	return mapToJob(result), nil
+}
+
+// GetByID returns the job, or nil, nil if it does not exist.
+func (r *JobRepository) GetByID(ctx context.Context, id string) (*models.Job, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	result, err := r.queries.GetJob(ctx, pgUUID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return mapToJob(result), nil
+}
+
+// ClaimNext marks the oldest due job as running and returns it, or nil, nil if
+// no job is due. Concurrent workers never claim the same job.
+func (r *JobRepository) ClaimNext(ctx context.Context) (*models.Job, error) {
+	result, err := r.queries.ClaimNextJob(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	return mapToJob(result), nil
+}
+
+func (r *JobRepository) Complete(ctx context.Context, id string, result any) (*models.Job, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal job result: %w", err)
+	}
+	arg := dbgenerated.CompleteJobParams{
+		ID:     pgtype.UUID{Bytes: [16]byte(parsedUUID), Valid: true},
+		Result: resultJSON,
+	}
+	job, err := r.queries.CompleteJob(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete job: %w", err)
+	}
+	return mapToJob(job), nil
+}
+
+// Fail records a failed attempt. The job runs again at retryAt unless it has
+// no attempts left, in which case it is failed for good.
+func (r *JobRepository) Fail(ctx context.Context, id, errMsg string, retryAt time.Time) (*models.Job, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	arg := dbgenerated.FailJobParams{
+		Error:   pgtype.Text{String: errMsg, Valid: true},
+		RetryAt: pgtype.Timestamptz{Time: retryAt, Valid: true},
+		ID:      pgtype.UUID{Bytes: [16]byte(parsedUUID), Valid: true},
+	}
+	job, err := r.queries.FailJob(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fail job: %w", err)
+	}
+	return mapToJob(job), nil
+}
+
+// RequeueStale returns jobs that have been running since before lockedBefore
+// to the queue and reports how many there were.
+func (r *JobRepository) RequeueStale(ctx context.Context, lockedBefore time.Time) (int64, error) {
+	count, err := r.queries.RequeueStaleJobs(ctx, pgtype.Timestamptz{Time: lockedBefore, Valid: true})
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue stale jobs: %w", err)
+	}
+	return count, nil
+}
+
+func mapToJob(j dbgenerated.Job) *models.Job {
+	var completedAt *string
+	if j.CompletedAt.Valid {
+		s := j.CompletedAt.Time.Format(time.RFC3339)
+		completedAt = &s
+	}
+
+	return &models.Job{
+		ID:          j.ID.String(),
+		Kind:        models.JobKind(j.Kind),
+		UserID:      j.UserID,
+		Status:      models.JobStatus(j.Status),
+		Payload:     j.Payload,
+		Result:      j.Result,
+		Error:       mapTextToPtr(j.Error),
+		Attempts:    int(j.Attempts),
+		MaxAttempts: int(j.MaxAttempts),
+		CallbackURL: mapTextToPtr(j.CallbackUrl),
+		CreatedAt:   j.CreatedAt.Time.Format(time.RFC3339),
+		UpdatedAt:   j.UpdatedAt.Time.Format(time.RFC3339),
+		CompletedAt: completedAt,
+	}
+}
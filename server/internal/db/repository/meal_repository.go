@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"iter"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
 	"github.com/simhozebs/mugo/internal/models"
@@ -77,6 +80,41 @@ func (r *MealLogRepository) Create(ctx context.Context, userID, conversationID,
 	return mapToMealLog(result), nil
 }
 
+// Import creates a manually entered meal log for a row imported from
+// another tracker. It returns nil if the user already imported the row with
+// the same source and key.
+func (r *MealLogRepository) Import(ctx context.Context, userID, source, key, foodName, mealType string, recordedAt time.Time, macros models.Macros) (*models.MealLog, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	macrosJSON, err := json.Marshal(macros)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal macros: %w", err)
+	}
+	arg := dbgenerated.ImportMealLogParams{
+		UserID:       pgUUID,
+		FoodName:     foodName,
+		MealType:     mealType,
+		RecordedAt:   pgtype.Timestamptz{Time: recordedAt, Valid: true},
+		Macros:       macrosJSON,
+		ImportSource: pgtype.Text{String: source, Valid: true},
+		ImportKey:    pgtype.Text{String: key, Valid: true},
+	}
+	result, err := r.queries.ImportMealLog(ctx, arg)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to import meal log: %w", err)
+	}
+	return mapToMealLog(result), nil
+}
+
 func (r *MealLogRepository) GetByID(ctx context.Context, id string) (*models.MealLog, error) {
 	parsedUUID, err := uuid.Parse(id)
 	if err != nil {
@@ -118,6 +156,148 @@ func (r *MealLogRepository) ListByUser(ctx context.Context, userID string, limit
 	return mealLogs, nil
 }
 
+// mealPageSize is how many meal logs AllByUser reads per query.
+const mealPageSize = 500
+
+// AllByUser iterates over all of the user's meal logs, oldest first. It reads
+// them a page at a time, so the logs are never all held in memory.
+func (r *MealLogRepository) AllByUser(ctx context.Context, userID string) iter.Seq2[*models.MealLog, error] {
+	return func(yield func(*models.MealLog, error) bool) {
+		parsedUUID, err := uuid.Parse(userID)
+		if err != nil {
+			yield(nil, fmt.Errorf("invalid user UUID: %w", err))
+			return
+		}
+		arg := dbgenerated.ListMealLogsByUserAfterParams{
+			UserID: pgtype.UUID{
+				Bytes: [16]byte(parsedUUID),
+				Valid: true,
+			},
+			PageSize: mealPageSize,
+		}
+		for {
+			results, err := r.queries.ListMealLogsByUserAfter(ctx, arg)
+			if err != nil {
+				yield(nil, fmt.Errorf("failed to list meal logs: %w", err))
+				return
+			}
+			for _, m := range results {
+				if !yield(mapToMealLog(m), nil) {
+					return
+				}
+			}
+			if len(results) < mealPageSize {
+				return
+			}
+			last := results[len(results)-1]
+			arg.AfterRecordedAt = last.RecordedAt
+			arg.AfterID = last.ID
+		}
+	}
+}
+
+// Search returns the user's meals matching the filter, best match first.
+// Pages after the first pass the rank and ID of the last result of the
+// previous page as afterRank and afterID.
+func (r *MealLogRepository) Search(ctx context.Context, userID string, filter models.MealSearchFilter, afterRank *float64, afterID string, limit int) ([]*models.MealSearchResult, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	arg := dbgenerated.SearchMealLogsParams{
+		UserID: pgtype.UUID{
+			Bytes: [16]byte(parsedUUID),
+			Valid: true,
+		},
+		Query:          filter.Query,
+		MealType:       pgtype.Text{String: filter.MealType, Valid: filter.MealType != ""},
+		FoodSource:     pgtype.Text{String: filter.FoodSource, Valid: filter.FoodSource != ""},
+		RecordedFrom:   pgtype.Timestamptz{Time: filter.RecordedFrom, Valid: !filter.RecordedFrom.IsZero()},
+		RecordedBefore: pgtype.Timestamptz{Time: filter.RecordedBefore, Valid: !filter.RecordedBefore.IsZero()},
+		PageSize:       int32(limit),
+	}
+	if filter.MinCalories != nil {
+		arg.MinCalories = pgtype.Float8{Float64: *filter.MinCalories, Valid: true}
+	}
+	if filter.MaxCalories != nil {
+		arg.MaxCalories = pgtype.Float8{Float64: *filter.MaxCalories, Valid: true}
+	}
+	if afterRank != nil {
+		parsedAfterID, err := uuid.Parse(afterID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid meal UUID: %w", err)
+		}
+		arg.AfterRank = pgtype.Float8{Float64: *afterRank, Valid: true}
+		arg.AfterID = pgtype.UUID{Bytes: [16]byte(parsedAfterID), Valid: true}
+	}
+
+	results, err := r.queries.SearchMealLogs(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search meal logs: %w", err)
+	}
+	matches := make([]*models.MealSearchResult, len(results))
+	for i, m := range results {
+		matches[i] = &models.MealSearchResult{
+			Meal: mapToMealLog(dbgenerated.MealLog{
+				ID:             m.ID,
+				UserID:         m.UserID,
+				ConversationID: m.ConversationID,
+				FoodName:       m.FoodName,
+				MealType:       m.MealType,
+				RecordedAt:     m.RecordedAt,
+				Macros:         m.Macros,
+				Assumptions:    m.Assumptions,
+				FoodSource:     m.FoodSource,
+				RawResponse:    m.RawResponse,
+				CreatedAt:      m.CreatedAt,
+				ImportSource:   m.ImportSource,
+				ImportKey:      m.ImportKey,
+			}),
+			Rank: m.Rank,
+		}
+	}
+	return matches, nil
+}
+
+// ListFrequent returns the foods the user logged most often since
+// recordedFrom, most logged first.
+func (r *MealLogRepository) ListFrequent(ctx context.Context, userID string, recordedFrom time.Time, limit int) ([]*models.FrequentMeal, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.ListFrequentMealsByUserParams{
+		UserID:       pgUUID,
+		RecordedFrom: pgtype.Timestamptz{Time: recordedFrom, Valid: true},
+		RowLimit:     int32(limit),
+	}
+	results, err := r.queries.ListFrequentMealsByUser(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list frequent meals: %w", err)
+	}
+	meals := make([]*models.FrequentMeal, len(results))
+	for i, m := range results {
+		meals[i] = &models.FrequentMeal{
+			FoodName:      m.FoodName,
+			LatestMealID:  m.LatestMealID.String(),
+			UsualMealType: m.UsualMealType,
+			TimesLogged:   int(m.TimesLogged),
+			LastLoggedAt:  m.LastLoggedAt.Time.Format(time.RFC3339),
+			AverageMacros: models.Macros{
+				Calories: m.AvgCalories,
+				Protein:  m.AvgProtein,
+				Carbs:    m.AvgCarbs,
+				Fat:      m.AvgFat,
+			},
+		}
+	}
+	return meals, nil
+}
+
 func (r *MealLogRepository) ListByUserAndDate(ctx context.Context, userID string, date time.Time) ([]*models.MealLog, error) {
 	parsedUUID, err := uuid.Parse(userID)
 	if err != nil {
@@ -152,8 +332,9 @@ func (r *MealLogRepository) ListByUserAndDateRange(ctx context.Context, userID s
 		Valid: true,
 	}
 	arg := dbgenerated.ListMealLogsByUserAndDateRangeParams{
-		UserID:     pgUUID,
-		RecordedAt: pgtype.Timestamptz{Time: startDate, Valid: true},
+		UserID:       pgUUID,
+		RecordedAt:   pgtype.Timestamptz{Time: startDate, Valid: true},
+		RecordedAt_2: pgtype.Timestamptz{Time: endDate, Valid: true},
 	}
 	results, err := r.queries.ListMealLogsByUserAndDateRange(ctx, arg)
 	if err != nil {
@@ -186,6 +367,137 @@ func (r *MealLogRepository) ListByConversation(ctx context.Context, conversation
 	return mealLogs, nil
 }
 
+// GetByIDForUpdate is GetByID that locks the meal log until the end of the
+// transaction it runs in. It returns nil if the meal log does not exist.
+func (r *MealLogRepository) GetByIDForUpdate(ctx context.Context, id string) (*models.MealLog, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	result, err := r.queries.GetMealLogForUpdate(ctx, pgUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get meal log: %w", err)
+	}
+	return mapToMealLog(result), nil
+}
+
+// Revise replaces the macros and assumptions of a meal log and records the
+// edit as a revision of kind with factor. It should run in a transaction,
+// after the meal log was read with GetByIDForUpdate.
+func (r *MealLogRepository) Revise(ctx context.Context, meal *models.MealLog, kind string, factor float64, macros models.Macros, assumptions []models.Assumption) (*models.MealLog, *models.MealRevision, error) {
+	parsedUUID, err := uuid.Parse(meal.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	parsedUserUUID, err := uuid.Parse(meal.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUserUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUserUUID),
+		Valid: true,
+	}
+
+	previousMacrosJSON, err := json.Marshal(meal.Macros)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal macros: %w", err)
+	}
+	previousAssumptionsJSON, err := json.Marshal(meal.Assumptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal assumptions: %w", err)
+	}
+	macrosJSON, err := json.Marshal(macros)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal macros: %w", err)
+	}
+	assumptionsJSON, err := json.Marshal(assumptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal assumptions: %w", err)
+	}
+
+	updated, err := r.queries.UpdateMealLogPortion(ctx, dbgenerated.UpdateMealLogPortionParams{
+		ID:          pgUUID,
+		Macros:      macrosJSON,
+		Assumptions: assumptionsJSON,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update meal log: %w", err)
+	}
+	revision, err := r.queries.CreateMealRevision(ctx, dbgenerated.CreateMealRevisionParams{
+		MealLogID:           pgUUID,
+		UserID:              pgUserUUID,
+		Kind:                kind,
+		Factor:              factor,
+		PreviousMacros:      previousMacrosJSON,
+		PreviousAssumptions: previousAssumptionsJSON,
+		Macros:              macrosJSON,
+		Assumptions:         assumptionsJSON,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create meal revision: %w", err)
+	}
+	return mapToMealLog(updated), mapToMealRevision(revision), nil
+}
+
+// ListRevisions returns the revisions of a meal log, oldest first.
+func (r *MealLogRepository) ListRevisions(ctx context.Context, mealID string) ([]*models.MealRevision, error) {
+	parsedUUID, err := uuid.Parse(mealID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	results, err := r.queries.ListMealRevisions(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list meal revisions: %w", err)
+	}
+	revisions := make([]*models.MealRevision, len(results))
+	for i, rev := range results {
+		revisions[i] = mapToMealRevision(rev)
+	}
+	return revisions, nil
+}
+
+// ListRevisionsByUser returns the revisions of all the user's meal logs,
+// oldest first.
+func (r *MealLogRepository) ListRevisionsByUser(ctx context.Context, userID string, limit, offset int) ([]*models.MealRevision, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.ListMealRevisionsByUserParams{
+		UserID: pgUUID,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	}
+	results, err := r.queries.ListMealRevisionsByUser(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list meal revisions: %w", err)
+	}
+	revisions := make([]*models.MealRevision, len(results))
+	for i, rev := range results {
+		revisions[i] = mapToMealRevision(rev)
+	}
+	return revisions, nil
+}
+
 func (r *MealLogRepository) Delete(ctx context.Context, id string) error {
 	parsedUUID, err := uuid.Parse(id)
 	if err != nil {
@@ -234,3 +546,26 @@ func mapToMealLog(m dbgenerated.MealLog) *models.MealLog {
 		CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
 	}
 }
+
+func mapToMealRevision(rev dbgenerated.MealRevision) *models.MealRevision {
+	var previousMacros, macros models.Macros
+	json.Unmarshal(rev.PreviousMacros, &previousMacros)
+	json.Unmarshal(rev.Macros, &macros)
+
+	var previousAssumptions, assumptions []models.Assumption
+	json.Unmarshal(rev.PreviousAssumptions, &previousAssumptions)
+	json.Unmarshal(rev.Assumptions, &assumptions)
+
+	return &models.MealRevision{
+		ID:                  rev.ID.String(),
+		MealID:              rev.MealLogID.String(),
+		UserID:              rev.UserID.String(),
+		Kind:                rev.Kind,
+		Factor:              rev.Factor,
+		PreviousMacros:      previousMacros,
+		PreviousAssumptions: previousAssumptions,
+		Macros:              macros,
+		Assumptions:         assumptions,
+		CreatedAt:           rev.CreatedAt.Time.Format(time.RFC3339),
+	}
+}
@@ -102,6 +102,7 @@ func (r *NutritionSummaryRepository) ListDailyByDateRange(ctx context.Context, u
 	arg := dbgenerated.ListDailyNutritionSummariesByUserAndDateRangeParams{
 		UserID: pgUUID,
 		Date:   pgtype.Date{Time: startDate, Valid: true},
+		Date_2: pgtype.Date{Time: endDate, Valid: true},
 	}
 	results, err := r.queries.ListDailyNutritionSummariesByUserAndDateRange(ctx, arg)
 	if err != nil {
@@ -163,6 +164,31 @@ func (r *NutritionSummaryRepository) GetWeekly(ctx context.Context, userID strin
 	return mapToWeeklySummary(result), nil
 }
 
+func (r *NutritionSummaryRepository) ListWeeklyByUser(ctx context.Context, userID string, limit, offset int) ([]*models.WeeklyNutritionSummary, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.ListWeeklyNutritionSummariesByUserParams{
+		UserID: pgUUID,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	}
+	results, err := r.queries.ListWeeklyNutritionSummariesByUser(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list weekly nutrition summaries: %w", err)
+	}
+	summaries := make([]*models.WeeklyNutritionSummary, len(results))
+	for i, s := range results {
+		summaries[i] = mapToWeeklySummary(s)
+	}
+	return summaries, nil
+}
+
 func (r *NutritionSummaryRepository) ListWeeklyByDateRange(ctx context.Context, userID string, startDate, endDate time.Time) ([]*models.WeeklyNutritionSummary, error) {
 	parsedUUID, err := uuid.Parse(userID)
 	if err != nil {
@@ -173,8 +199,9 @@ func (r *NutritionSummaryRepository) ListWeeklyByDateRange(ctx context.Context,
 		Valid: true,
 	}
 	arg := dbgenerated.ListWeeklyNutritionSummariesByUserAndDateRangeParams{
-		UserID:        pgUUID,
-		WeekStartDate: pgtype.Date{Time: startDate, Valid: true},
+		UserID:          pgUUID,
+		WeekStartDate:   pgtype.Date{Time: startDate, Valid: true},
+		WeekStartDate_2: pgtype.Date{Time: endDate, Valid: true},
 	}
 	results, err := r.queries.ListWeeklyNutritionSummariesByUserAndDateRange(ctx, arg)
 	if err != nil {
@@ -187,6 +214,77 @@ func (r *NutritionSummaryRepository) ListWeeklyByDateRange(ctx context.Context,
 	return summaries, nil
 }
 
+// RebuildDaily recomputes the summary of a UTC day from the user's meal logs.
+func (r *NutritionSummaryRepository) RebuildDaily(ctx context.Context, userID string, date time.Time) (*models.DailyNutritionSummary, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.RebuildDailyNutritionSummaryParams{
+		UserID: pgUUID,
+		Date:   pgtype.Date{Time: date, Valid: true},
+	}
+	result, err := r.queries.RebuildDailyNutritionSummary(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild daily nutrition summary: %w", err)
+	}
+	return mapToDailySummary(result), nil
+}
+
+// RebuildWeekly recomputes the summary of the week starting on weekStartDate
+// from the user's meal logs.
+func (r *NutritionSummaryRepository) RebuildWeekly(ctx context.Context, userID string, weekStartDate time.Time) (*models.WeeklyNutritionSummary, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.RebuildWeeklyNutritionSummaryParams{
+		UserID:        pgUUID,
+		WeekStartDate: pgtype.Date{Time: weekStartDate, Valid: true},
+	}
+	result, err := r.queries.RebuildWeeklyNutritionSummary(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild weekly nutrition summary: %w", err)
+	}
+	return mapToWeeklySummary(result), nil
+}
+
+// Rebuild recomputes the daily summaries of the given UTC days and the
+// weekly summaries of the weeks, starting on Monday, that contain them.
+func (r *NutritionSummaryRepository) Rebuild(ctx context.Context, userID string, dates []time.Time) error {
+	days := map[time.Time]bool{}
+	weeks := map[time.Time]bool{}
+	for _, date := range dates {
+		day := date.UTC().Truncate(24 * time.Hour)
+		days[day] = true
+		weeks[WeekStart(day)] = true
+	}
+	for day := range days {
+		if _, err := r.RebuildDaily(ctx, userID, day); err != nil {
+			return err
+		}
+	}
+	for week := range weeks {
+		if _, err := r.RebuildWeekly(ctx, userID, week); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WeekStart returns the Monday of the week containing day.
+func WeekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
 func mapToDailySummary(s dbgenerated.DailyNutritionSummary) *models.DailyNutritionSummary {
 	return &models.DailyNutritionSummary{
 		ID:            s.ID.String(),
@@ -222,8 +320,9 @@ func mapToWeeklySummary(s dbgenerated.WeeklyNutritionSummary) *models.WeeklyNutr
 }
 
 func parseNumeric(n pgtype.Numeric) float64 {
-	if !n.Valid || n.Int == nil {
+	f, err := n.Float64Value()
+	if err != nil || !f.Valid {
 		return 0
 	}
-	return float64(n.Int.Int64())
+	return f.Float64
 }
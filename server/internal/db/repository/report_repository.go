@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+type ReportRepository struct {
+	queries *dbgenerated.Queries
+}
+
+func NewReportRepository(queries *dbgenerated.Queries) *ReportRepository {
+	return &ReportRepository{queries: queries}
+}
+
+func (r *ReportRepository) Create(ctx context.Context, userID string, reportType models.ReportType, periodStart, periodEnd time.Time, content string) (*models.Report, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.CreateReportParams{
+		UserID:      pgUUID,
+		ReportType:  string(reportType),
+		PeriodStart: pgtype.Date{Time: periodStart, Valid: true},
+		PeriodEnd:   pgtype.Date{Time: periodEnd, Valid: true},
+		Content:     content,
+	}
+	result, err := r.queries.CreateReport(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+	return mapToReport(result), nil
+}
+
+func (r *ReportRepository) GetByID(ctx context.Context, id string) (*models.Report, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	result, err := r.queries.GetReport(ctx, pgUUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get report: %w", err)
+	}
+	return mapToReport(result), nil
+}
+
+func (r *ReportRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*models.Report, error) {
+	parsedUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.ListReportsByUserParams{
+		UserID: pgUUID,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	}
+	results, err := r.queries.ListReportsByUser(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+	reports := make([]*models.Report, len(results))
+	for i, rep := range results {
+		reports[i] = mapToReport(rep)
+	}
+	return reports, nil
+}
+
+func mapToReport(r dbgenerated.Report) *models.Report {
+	return &models.Report{
+		ID:          r.ID.String(),
+		UserID:      r.UserID.String(),
+		ReportType:  models.ReportType(r.ReportType),
+		PeriodStart: r.PeriodStart.Time.Format("2006-01-02"),
+		PeriodEnd:   r.PeriodEnd.Time.Format("2006-01-02"),
+		Content:     r.Content,
+		CreatedAt:   r.CreatedAt.Time.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// SessionRepository stores ADK sessions, their events and the app and user
+// state shared between sessions.
+type SessionRepository struct {
+	queries *dbgenerated.Queries
+}
+
+func NewSessionRepository(queries *dbgenerated.Queries) *SessionRepository {
+	return &SessionRepository{queries: queries}
+}
+
+// Create stores a new session. If userID is the ID of a users row, the session
+// is linked to that user's conversation with the same session ID, which is
+// created if needed.
+func (r *SessionRepository) Create(ctx context.Context, appName, userID, sessionID string, state map[string]any) (*models.AgentSession, error) {
+	var conversationID pgtype.UUID
+	if parsedUUID, err := uuid.Parse(userID); err == nil {
+		linked, err := r.queries.LinkSessionConversation(ctx, dbgenerated.LinkSessionConversationParams{
+			SessionID: sessionID,
+			UserID:    pgtype.UUID{Bytes: [16]byte(parsedUUID), Valid: true},
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to link conversation: %w", err)
+		}
+		conversationID = linked
+	}
+
+	stateJSON, err := marshalState(state)
+	if err != nil {
+		return nil, err
+	}
+	arg := dbgenerated.CreateADKSessionParams{
+		AppName:        appName,
+		UserID:         userID,
+		ID:             sessionID,
+		ConversationID: conversationID,
+		State:          stateJSON,
+	}
+	result, err := r.queries.CreateADKSession(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return mapToAgentSession(result), nil
+}
+
+// Get returns the session, or nil, nil if it does not exist.
+func (r *SessionRepository) Get(ctx context.Context, appName, userID, sessionID string) (*models.AgentSession, error) {
+	arg := dbgenerated.GetADKSessionParams{
+		AppName: appName,
+		UserID:  userID,
+		ID:      sessionID,
+	}
+	result, err := r.queries.GetADKSession(ctx, arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return mapToAgentSession(result), nil
+}
+
+// List returns the sessions of an app, most recently updated first. An empty
+// userID lists the sessions of every user.
+func (r *SessionRepository) List(ctx context.Context, appName, userID string) ([]*models.AgentSession, error) {
+	var results []dbgenerated.AdkSession
+	var err error
+	if userID == "" {
+		results, err = r.queries.ListADKSessionsByApp(ctx, appName)
+	} else {
+		results, err = r.queries.ListADKSessionsByUser(ctx, dbgenerated.ListADKSessionsByUserParams{
+			AppName: appName,
+			UserID:  userID,
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sessions := make([]*models.AgentSession, len(results))
+	for i, s := range results {
+		sessions[i] = mapToAgentSession(s)
+	}
+	return sessions, nil
+}
+
+// MergeState merges a delta into the session state and bumps its update time.
+func (r *SessionRepository) MergeState(ctx context.Context, appName, userID, sessionID string, delta map[string]any, updatedAt time.Time) error {
+	deltaJSON, err := marshalState(delta)
+	if err != nil {
+		return err
+	}
+	arg := dbgenerated.MergeADKSessionStateParams{
+		Delta:     deltaJSON,
+		UpdatedAt: pgtype.Timestamptz{Time: updatedAt, Valid: true},
+		AppName:   appName,
+		UserID:    userID,
+		ID:        sessionID,
+	}
+	if err := r.queries.MergeADKSessionState(ctx, arg); err != nil {
+		return fmt.Errorf("failed to update session state: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes the session and its events.
+func (r *SessionRepository) Delete(ctx context.Context, appName, userID, sessionID string) error {
+	arg := dbgenerated.DeleteADKSessionParams{
+		AppName: appName,
+		UserID:  userID,
+		ID:      sessionID,
+	}
+	if err := r.queries.DeleteADKSession(ctx, arg); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// AppendEvent stores an event of the session.
+func (r *SessionRepository) AppendEvent(ctx context.Context, appName, userID, sessionID string, event models.AgentEvent) error {
+	arg := dbgenerated.CreateADKEventParams{
+		ID:           event.ID,
+		AppName:      appName,
+		UserID:       userID,
+		SessionID:    sessionID,
+		InvocationID: event.InvocationID,
+		Author:       event.Author,
+		Event:        event.Event,
+		Timestamp:    pgtype.Timestamptz{Time: event.Timestamp, Valid: true},
+	}
+	if err := r.queries.CreateADKEvent(ctx, arg); err != nil {
+		return fmt.Errorf("failed to create session event: %w", err)
+	}
+	return nil
+}
+
+// ListAllByUser returns the sessions of a user across every app, oldest
+// first.
+func (r *SessionRepository) ListAllByUser(ctx context.Context, userID string) ([]*models.AgentSession, error) {
+	results, err := r.queries.ListADKSessionsOfUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	sessions := make([]*models.AgentSession, len(results))
+	for i, s := range results {
+		sessions[i] = mapToAgentSession(s)
+	}
+	return sessions, nil
+}
+
+// ListEvents returns the events of the session in the order they happened.
+func (r *SessionRepository) ListEvents(ctx context.Context, appName, userID, sessionID string) ([]models.AgentEvent, error) {
+	arg := dbgenerated.ListADKEventsParams{
+		AppName:   appName,
+		UserID:    userID,
+		SessionID: sessionID,
+	}
+	results, err := r.queries.ListADKEvents(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session events: %w", err)
+	}
+	events := make([]models.AgentEvent, len(results))
+	for i, e := range results {
+		events[i] = models.AgentEvent{
+			ID:           e.ID,
+			InvocationID: e.InvocationID,
+			Author:       e.Author,
+			Event:        e.Event,
+			Timestamp:    e.Timestamp.Time,
+		}
+	}
+	return events, nil
+}
+
+// GetAppState returns the state shared by every session of an app.
+func (r *SessionRepository) GetAppState(ctx context.Context, appName string) (map[string]any, error) {
+	result, err := r.queries.GetADKAppState(ctx, appName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app state: %w", err)
+	}
+	return unmarshalState(result), nil
+}
+
+// MergeAppState merges a delta into the app state and returns the result.
+func (r *SessionRepository) MergeAppState(ctx context.Context, appName string, delta map[string]any) (map[string]any, error) {
+	deltaJSON, err := marshalState(delta)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.queries.MergeADKAppState(ctx, dbgenerated.MergeADKAppStateParams{
+		AppName: appName,
+		State:   deltaJSON,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update app state: %w", err)
+	}
+	return unmarshalState(result), nil
+}
+
+// GetUserState returns the state shared by every session of a user in an app.
+func (r *SessionRepository) GetUserState(ctx context.Context, appName, userID string) (map[string]any, error) {
+	result, err := r.queries.GetADKUserState(ctx, dbgenerated.GetADKUserStateParams{
+		AppName: appName,
+		UserID:  userID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return map[string]any{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user state: %w", err)
+	}
+	return unmarshalState(result), nil
+}
+
+// MergeUserState merges a delta into the user state and returns the result.
+func (r *SessionRepository) MergeUserState(ctx context.Context, appName, userID string, delta map[string]any) (map[string]any, error) {
+	deltaJSON, err := marshalState(delta)
+	if err != nil {
+		return nil, err
+	}
+	result, err := r.queries.MergeADKUserState(ctx, dbgenerated.MergeADKUserStateParams{
+		AppName: appName,
+		UserID:  userID,
+		State:   deltaJSON,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user state: %w", err)
+	}
+	return unmarshalState(result), nil
+}
+
+func marshalState(state map[string]any) ([]byte, error) {
+	if state == nil {
+		state = map[string]any{}
+	}
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	return stateJSON, nil
+}
+
+func unmarshalState(data []byte) map[string]any {
+	state := map[string]any{}
+	if data != nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+func mapToAgentSession(s dbgenerated.AdkSession) *models.AgentSession {
+	var conversationID *string
+	if s.ConversationID.Valid {
+		id := s.ConversationID.String()
+		conversationID = &id
+	}
+
+	return &models.AgentSession{
+		AppName:        s.AppName,
+		UserID:         s.UserID,
+		ID:             s.ID,
+		ConversationID: conversationID,
+		State:          unmarshalState(s.State),
+		CreatedAt:      s.CreatedAt.Time,
+		UpdatedAt:      s.UpdatedAt.Time,
+	}
+}
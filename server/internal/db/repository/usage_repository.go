@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+type UsageRepository struct {
+	queries *dbgenerated.Queries
+}
+
+func NewUsageRepository(queries *dbgenerated.Queries) *UsageRepository {
+	return &UsageRepository{queries: queries}
+}
+
+// ConsumeInvocation counts an agent invocation of the user on day, unless the
+// user already made dailyLimit invocations that day. It returns the day's
+// count and whether the invocation was counted.
+func (r *UsageRepository) ConsumeInvocation(ctx context.Context, userID string, day time.Time, dailyLimit int) (int, bool, error) {
+	arg := dbgenerated.ConsumeAgentInvocationParams{
+		UserID:     userID,
+		Day:        pgtype.Date{Time: day, Valid: true},
+		DailyLimit: int32(dailyLimit),
+	}
+	invocations, err := r.queries.ConsumeAgentInvocation(ctx, arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dailyLimit, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to consume agent invocation: %w", err)
+	}
+	return int(invocations), true, nil
+}
+
+// Record stores the token usage of an LLM call.
+func (r *UsageRepository) Record(ctx context.Context, usage models.LLMUsage) error {
+	arg := dbgenerated.RecordLLMUsageParams{
+		UserID:           usage.UserID,
+		AppName:          usage.AppName,
+		SessionID:        usage.SessionID,
+		InvocationID:     usage.InvocationID,
+		Model:            usage.Model,
+		PromptTokens:     int32(usage.PromptTokens),
+		CandidatesTokens: int32(usage.CandidatesTokens),
+		TotalTokens:      int32(usage.TotalTokens),
+	}
+	if usage.EstimatedCostUSD != nil {
+		arg.EstimatedCostUsd = pgtype.Float8{Float64: *usage.EstimatedCostUSD, Valid: true}
+	}
+	if err := r.queries.RecordLLMUsage(ctx, arg); err != nil {
+		return fmt.Errorf("failed to record LLM usage: %w", err)
+	}
+	return nil
+}
+
+// LinkMeal attributes the usage of an invocation to the meal it produced.
+func (r *UsageRepository) LinkMeal(ctx context.Context, invocationID, mealLogID string) error {
+	parsedUUID, err := uuid.Parse(mealLogID)
+	if err != nil {
+		return fmt.Errorf("invalid UUID: %w", err)
+	}
+	arg := dbgenerated.LinkLLMUsageToMealParams{
+		InvocationID: invocationID,
+		MealLogID: pgtype.UUID{
+			Bytes: [16]byte(parsedUUID),
+			Valid: true,
+		},
+	}
+	if err := r.queries.LinkLLMUsageToMeal(ctx, arg); err != nil {
+		return fmt.Errorf("failed to link LLM usage to meal: %w", err)
+	}
+	return nil
+}
+
+// ListByUser returns the user's usage records, oldest first.
+func (r *UsageRepository) ListByUser(ctx context.Context, userID string, limit, offset int) ([]*models.LLMUsageRecord, error) {
+	arg := dbgenerated.ListLLMUsageByUserParams{
+		UserID: userID,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	}
+	results, err := r.queries.ListLLMUsageByUser(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list LLM usage: %w", err)
+	}
+	records := make([]*models.LLMUsageRecord, len(results))
+	for i, row := range results {
+		record := &models.LLMUsageRecord{
+			ID:               row.ID,
+			AppName:          row.AppName,
+			SessionID:        row.SessionID,
+			InvocationID:     row.InvocationID,
+			Model:            row.Model,
+			PromptTokens:     int(row.PromptTokens),
+			CandidatesTokens: int(row.CandidatesTokens),
+			TotalTokens:      int(row.TotalTokens),
+			CreatedAt:        row.CreatedAt.Time.Format(time.RFC3339),
+		}
+		if row.EstimatedCostUsd.Valid {
+			record.EstimatedCostUSD = &row.EstimatedCostUsd.Float64
+		}
+		if row.MealLogID.Valid {
+			mealLogID := row.MealLogID.String()
+			record.MealLogID = &mealLogID
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// ListDaily returns the user's usage per UTC day from start to end
+// inclusive. Days without usage are left out.
+func (r *UsageRepository) ListDaily(ctx context.Context, userID string, start, end time.Time) ([]*models.DailyUsage, error) {
+	arg := dbgenerated.ListDailyLLMUsageByUserParams{
+		UserID:    userID,
+		StartDate: pgtype.Date{Time: start, Valid: true},
+		EndDate:   pgtype.Date{Time: end, Valid: true},
+	}
+	results, err := r.queries.ListDailyLLMUsageByUser(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily LLM usage: %w", err)
+	}
+	days := make([]*models.DailyUsage, len(results))
+	for i, row := range results {
+		days[i] = &models.DailyUsage{
+			Date: row.Day.Time.Format("2006-01-02"),
+			UsageTotals: models.UsageTotals{
+				Invocations:      int(row.Invocations),
+				LLMCalls:         int(row.LlmCalls),
+				PromptTokens:     row.PromptTokens,
+				CandidatesTokens: row.CandidatesTokens,
+				TotalTokens:      row.TotalTokens,
+				EstimatedCostUSD: row.EstimatedCostUsd,
+				UnpricedCalls:    int(row.UnpricedCalls),
+			},
+		}
+	}
+	return days, nil
+}
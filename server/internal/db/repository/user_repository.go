@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	dbgenerated "github.com/simhozebs/mugo/internal/db/dbgenerated"
 	"github.com/simhozebs/mugo/internal/models"
@@ -75,6 +77,76 @@ func (r *UserRepository) List(ctx context.Context) ([]*models.User, error) {
 	return users, nil
 }
 
+func (r *UserRepository) UpdateMetadata(ctx context.Context, id string, metadata map[string]interface{}) (*models.User, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	arg := dbgenerated.UpdateUserMetadataParams{
+		ID:       pgUUID,
+		Metadata: metadataJSON,
+	}
+	result, err := r.queries.UpdateUserMetadata(ctx, arg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user metadata: %w", err)
+	}
+	return mapToUser(result), nil
+}
+
+// GetPlan returns the rate limit plan of the user, or "" if the user has none
+// or does not exist.
+func (r *UserRepository) GetPlan(ctx context.Context, id string) (string, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	plan, err := r.queries.GetUserPlan(ctx, pgUUID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user plan: %w", err)
+	}
+	return plan.String, nil
+}
+
+// UpdatePlan sets the rate limit plan of the user; "" resets it to the
+// default plan. It returns nil if the user does not exist.
+func (r *UserRepository) UpdatePlan(ctx context.Context, id string, plan string) (*models.User, error) {
+	parsedUUID, err := uuid.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID: %w", err)
+	}
+	pgUUID := pgtype.UUID{
+		Bytes: [16]byte(parsedUUID),
+		Valid: true,
+	}
+	arg := dbgenerated.UpdateUserPlanParams{
+		ID:   pgUUID,
+		Plan: pgtype.Text{String: plan, Valid: plan != ""},
+	}
+	result, err := r.queries.UpdateUserPlan(ctx, arg)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user plan: %w", err)
+	}
+	return mapToUser(result), nil
+}
+
 func mapToUser(u dbgenerated.User) *models.User {
 	var metadata map[string]interface{}
 	if u.Metadata != nil {
@@ -84,6 +156,7 @@ func mapToUser(u dbgenerated.User) *models.User {
 		ID:        u.ID.String(),
 		Username:  u.Username,
 		Metadata:  metadata,
+		Plan:      u.Plan.String,
 		CreatedAt: u.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
 		UpdatedAt: u.UpdatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
 	}
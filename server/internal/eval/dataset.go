@@ -0,0 +1,52 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// Dataset is a versioned set of food descriptions with reference macros.
+type Dataset struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+	Cases       []Case `json:"cases"`
+}
+
+// Case is one food description and the macros an ideal estimate would return.
+type Case struct {
+	ID        string        `json:"id"`
+	Text      string        `json:"text"`
+	Reference models.Macros `json:"reference"`
+}
+
+// LoadDataset reads and validates a dataset file.
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var dataset Dataset
+	if err := json.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset: %w", err)
+	}
+	if dataset.Version == "" {
+		return nil, fmt.Errorf("dataset %s has no version", path)
+	}
+
+	seen := make(map[string]bool, len(dataset.Cases))
+	for i, c := range dataset.Cases {
+		if c.ID == "" || c.Text == "" {
+			return nil, fmt.Errorf("dataset case %d needs both id and text", i)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("dataset case id %q is duplicated", c.ID)
+		}
+		seen[c.ID] = true
+	}
+	return &dataset, nil
+}
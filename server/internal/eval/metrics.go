@@ -0,0 +1,189 @@
+package eval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// Estimator runs the nutrition agent on one case and returns its final text.
+type Estimator func(ctx context.Context, c Case) (string, error)
+
+// MacroErrors holds one error figure per macro.
+type MacroErrors struct {
+	Calories float64 `json:"calories"`
+	Protein  float64 `json:"protein"`
+	Carbs    float64 `json:"carbs"`
+	Fat      float64 `json:"fat"`
+}
+
+// CaseResult is the outcome of one dataset case.
+type CaseResult struct {
+	ID              string               `json:"id"`
+	Text            string               `json:"text"`
+	Reference       models.Macros        `json:"reference"`
+	Predicted       *models.Macros       `json:"predicted,omitempty"`
+	AbsError        *MacroErrors         `json:"abs_error,omitempty"`
+	Valid           bool                 `json:"valid"`
+	Error           string               `json:"error,omitempty"`
+	AssumptionCount int                  `json:"assumption_count"`
+	Meta            *models.EstimateMeta `json:"meta,omitempty"`
+}
+
+// AssumptionStats describes how many assumptions valid estimates listed.
+type AssumptionStats struct {
+	Mean float64 `json:"mean"`
+	Min  int     `json:"min"`
+	Max  int     `json:"max"`
+}
+
+// Summary aggregates the case results. MAE and MAPE only cover valid
+// estimates, and MAPE skips cases whose reference value is zero.
+type Summary struct {
+	Cases          int             `json:"cases"`
+	Valid          int             `json:"valid"`
+	SchemaValidity float64         `json:"schema_validity"`
+	MAE            MacroErrors     `json:"mae"`
+	MAPE           MacroErrors     `json:"mape"`
+	Assumptions    AssumptionStats `json:"assumptions"`
+}
+
+// Run evaluates every case of the dataset in order.
+func Run(ctx context.Context, dataset *Dataset, estimate Estimator) []CaseResult {
+	results := make([]CaseResult, len(dataset.Cases))
+	for i, c := range dataset.Cases {
+		text, err := estimate(ctx, c)
+		results[i] = Evaluate(c, text, err)
+	}
+	return results
+}
+
+// Evaluate scores a single estimate against its case.
+func Evaluate(c Case, finalText string, runErr error) CaseResult {
+	result := CaseResult{ID: c.ID, Text: c.Text, Reference: c.Reference}
+	if runErr != nil {
+		result.Error = fmt.Sprintf("agent run failed: %v", runErr)
+		return result
+	}
+
+	payload, err := ValidatePayload(finalText)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Valid = true
+	result.Predicted = &payload.Macros
+	result.AssumptionCount = len(payload.Assumptions)
+	result.Meta = payload.Meta
+	result.AbsError = &MacroErrors{
+		Calories: round(math.Abs(payload.Macros.Calories - c.Reference.Calories)),
+		Protein:  round(math.Abs(payload.Macros.Protein - c.Reference.Protein)),
+		Carbs:    round(math.Abs(payload.Macros.Carbs - c.Reference.Carbs)),
+		Fat:      round(math.Abs(payload.Macros.Fat - c.Reference.Fat)),
+	}
+	return result
+}
+
+// ValidatePayload strictly decodes the agent output and checks it against the
+// nutrition response schema.
+func ValidatePayload(text string) (*models.NutritionPayload, error) {
+	if text == "" {
+		return nil, fmt.Errorf("empty response")
+	}
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(text)))
+	dec.DisallowUnknownFields()
+	var payload models.NutritionPayload
+	if err := dec.Decode(&payload); err != nil {
+		return nil, fmt.Errorf("response did not match schema: %w", err)
+	}
+
+	m := payload.Macros
+	if m.Calories < 0 || m.Protein < 0 || m.Carbs < 0 || m.Fat < 0 {
+		return nil, fmt.Errorf("response has negative macros: %+v", m)
+	}
+	switch payload.MealType {
+	case "", models.MealTypeBreakfast, models.MealTypeLunch, models.MealTypeDinner, models.MealTypeSnack, models.MealTypeUnknown:
+	default:
+		return nil, fmt.Errorf("response has unknown meal_type %q", payload.MealType)
+	}
+	return &payload, nil
+}
+
+// Summarize aggregates case results into dataset-level metrics.
+func Summarize(results []CaseResult) Summary {
+	summary := Summary{Cases: len(results)}
+
+	var absSum, pctSum MacroErrors
+	var pctCount [4]int
+	assumptionTotal := 0
+	for _, r := range results {
+		if !r.Valid {
+			continue
+		}
+		summary.Valid++
+
+		absSum.Calories += r.AbsError.Calories
+		absSum.Protein += r.AbsError.Protein
+		absSum.Carbs += r.AbsError.Carbs
+		absSum.Fat += r.AbsError.Fat
+
+		refs := [4]float64{r.Reference.Calories, r.Reference.Protein, r.Reference.Carbs, r.Reference.Fat}
+		errs := [4]float64{r.AbsError.Calories, r.AbsError.Protein, r.AbsError.Carbs, r.AbsError.Fat}
+		pcts := [4]*float64{&pctSum.Calories, &pctSum.Protein, &pctSum.Carbs, &pctSum.Fat}
+		for i := range refs {
+			if refs[i] > 0 {
+				*pcts[i] += errs[i] / refs[i] * 100
+				pctCount[i]++
+			}
+		}
+
+		assumptionTotal += r.AssumptionCount
+		if summary.Valid == 1 || r.AssumptionCount < summary.Assumptions.Min {
+			summary.Assumptions.Min = r.AssumptionCount
+		}
+		if r.AssumptionCount > summary.Assumptions.Max {
+			summary.Assumptions.Max = r.AssumptionCount
+		}
+	}
+
+	if summary.Cases > 0 {
+		summary.SchemaValidity = round(float64(summary.Valid) / float64(summary.Cases))
+	}
+	if summary.Valid == 0 {
+		return summary
+	}
+
+	n := float64(summary.Valid)
+	summary.MAE = MacroErrors{
+		Calories: round(absSum.Calories / n),
+		Protein:  round(absSum.Protein / n),
+		Carbs:    round(absSum.Carbs / n),
+		Fat:      round(absSum.Fat / n),
+	}
+	summary.MAPE = MacroErrors{
+		Calories: meanOrZero(pctSum.Calories, pctCount[0]),
+		Protein:  meanOrZero(pctSum.Protein, pctCount[1]),
+		Carbs:    meanOrZero(pctSum.Carbs, pctCount[2]),
+		Fat:      meanOrZero(pctSum.Fat, pctCount[3]),
+	}
+	summary.Assumptions.Mean = round(float64(assumptionTotal) / n)
+	return summary
+}
+
+func meanOrZero(sum float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return round(sum / float64(count))
+}
+
+// round keeps two decimals so reports diff cleanly between runs.
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,103 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Report is the diffable output of one evaluation run.
+type Report struct {
+	Dataset        string       `json:"dataset"`
+	DatasetVersion string       `json:"dataset_version"`
+	Mode           string       `json:"mode"`
+	ModelProvider  string       `json:"model_provider"`
+	ModelName      string       `json:"model_name"`
+	AgentVersion   string       `json:"agent_version,omitempty"`
+	Summary        Summary      `json:"summary"`
+	Cases          []CaseResult `json:"cases"`
+}
+
+// NewReport builds a report from case results. The model name and agent
+// version are taken from the first estimate stamped with them.
+func NewReport(dataset *Dataset, mode, modelProvider string, results []CaseResult) *Report {
+	report := &Report{
+		Dataset:        dataset.Name,
+		DatasetVersion: dataset.Version,
+		Mode:           mode,
+		ModelProvider:  modelProvider,
+		Summary:        Summarize(results),
+		Cases:          results,
+	}
+	for _, r := range results {
+		if r.Meta != nil {
+			report.ModelName = r.Meta.Model
+			report.AgentVersion = r.Meta.AgentVersion
+			break
+		}
+	}
+	return report
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// WriteMarkdown writes the report as Markdown tables.
+func (r *Report) WriteMarkdown(path string) error {
+	if err := os.WriteFile(path, []byte(r.Markdown()), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// Markdown renders the report as Markdown.
+func (r *Report) Markdown() string {
+	var b strings.Builder
+	s := r.Summary
+
+	fmt.Fprintf(&b, "# %s eval (%s)\n\n", r.Dataset, r.DatasetVersion)
+	fmt.Fprintf(&b, "- Mode: %s\n", r.Mode)
+	fmt.Fprintf(&b, "- Model: %s / %s\n", r.ModelProvider, r.ModelName)
+	fmt.Fprintf(&b, "- Agent: %s\n", r.AgentVersion)
+	fmt.Fprintf(&b, "- Schema validity: %d/%d (%.2f)\n", s.Valid, s.Cases, s.SchemaValidity)
+	fmt.Fprintf(&b, "- Assumptions per estimate: mean %.2f, min %d, max %d\n\n", s.Assumptions.Mean, s.Assumptions.Min, s.Assumptions.Max)
+
+	b.WriteString("| Metric | Calories | Protein | Carbs | Fat |\n")
+	b.WriteString("|---|---|---|---|---|\n")
+	fmt.Fprintf(&b, "| MAE | %.2f | %.2f | %.2f | %.2f |\n", s.MAE.Calories, s.MAE.Protein, s.MAE.Carbs, s.MAE.Fat)
+	fmt.Fprintf(&b, "| MAPE %% | %.2f | %.2f | %.2f | %.2f |\n\n", s.MAPE.Calories, s.MAPE.Protein, s.MAPE.Carbs, s.MAPE.Fat)
+
+	b.WriteString("## Cases\n\n")
+	b.WriteString("| Case | Valid | Calories (ref/pred) | Protein | Carbs | Fat | Assumptions | Error |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, c := range r.Cases {
+		if c.Predicted == nil {
+			fmt.Fprintf(&b, "| %s | no | %.1f/- | %.1f/- | %.1f/- | %.1f/- | - | %s |\n",
+				c.ID, c.Reference.Calories, c.Reference.Protein, c.Reference.Carbs, c.Reference.Fat, escapeCell(c.Error))
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | yes | %.1f/%.1f | %.1f/%.1f | %.1f/%.1f | %.1f/%.1f | %d | |\n",
+			c.ID,
+			c.Reference.Calories, c.Predicted.Calories,
+			c.Reference.Protein, c.Predicted.Protein,
+			c.Reference.Carbs, c.Predicted.Carbs,
+			c.Reference.Fat, c.Predicted.Fat,
+			c.AssumptionCount)
+	}
+	return b.String()
+}
+
+func escapeCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,87 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+var csvHeader = []string{
+	"id", "recorded_at", "meal_type", "food_name", "food_source",
+	"calories_kcal", "protein_g", "carbs_g", "fat_g",
+	"assumptions", "conversation_id", "created_at",
+}
+
+// WriteCSV writes the user's meal logs as CSV, one row per meal, oldest
+// first. Macros get a column each; assumptions are joined into one column.
+func WriteCSV(ctx context.Context, w io.Writer, database *db.Database, user *models.User) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for meal, err := range database.MealLogRepository.AllByUser(ctx, user.ID) {
+		if err != nil {
+			return err
+		}
+		if err := cw.Write(mealRecord(meal)); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// mealRecord returns the CSV row of a meal, matching csvHeader.
+func mealRecord(meal *models.MealLog) []string {
+	conversationID := ""
+	if meal.ConversationID != nil {
+		conversationID = *meal.ConversationID
+	}
+	return []string{
+		meal.ID,
+		meal.RecordedAt,
+		meal.MealType,
+		meal.FoodName,
+		meal.FoodSource,
+		formatFloat(meal.Macros.Calories),
+		formatFloat(meal.Macros.Protein),
+		formatFloat(meal.Macros.Carbs),
+		formatFloat(meal.Macros.Fat),
+		formatAssumptions(meal.Assumptions),
+		conversationID,
+		meal.CreatedAt,
+	}
+}
+
+// formatAssumptions flattens assumptions into "field=value unit (confidence)"
+// entries separated by "; ".
+func formatAssumptions(assumptions []models.Assumption) string {
+	entries := make([]string, 0, len(assumptions))
+	for _, a := range assumptions {
+		name := a.Field
+		if name == "" {
+			name = a.Category
+		}
+		entry := fmt.Sprintf("%s=%s", name, formatFloat(a.AssumedValue))
+		if a.Unit != "" {
+			entry += " " + a.Unit
+		}
+		if a.Confidence != "" {
+			entry += " (" + a.Confidence + ")"
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, "; ")
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
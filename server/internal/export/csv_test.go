@@ -0,0 +1,84 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+func TestFormatAssumptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		assumptions []models.Assumption
+		want        string
+	}{
+		{"none", nil, ""},
+		{"value only", []models.Assumption{{Field: "bread", AssumedValue: 2}}, "bread=2"},
+		{"unit and confidence", []models.Assumption{{Field: "oil_amount", AssumedValue: 1.5, Unit: "tbsp", Confidence: "low"}}, "oil_amount=1.5 tbsp (low)"},
+		{"category without field", []models.Assumption{{Category: "portion", AssumedValue: 250, Unit: "g"}}, "portion=250 g"},
+		{"several", []models.Assumption{
+			{Field: "rice_volume", AssumedValue: 0.75, Unit: "cup"},
+			{Field: "chicken_weight", AssumedValue: 150, Unit: "g", Confidence: "high"},
+		}, "rice_volume=0.75 cup; chicken_weight=150 g (high)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatAssumptions(tt.assumptions); got != tt.want {
+				t.Errorf("formatAssumptions = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMealRecordEscaping(t *testing.T) {
+	conversationID := "c1"
+	meal := &models.MealLog{
+		ID:             "m1",
+		RecordedAt:     "2024-03-01T08:00:00Z",
+		MealType:       "breakfast",
+		FoodName:       "Toast, \"extra\" butter\nand jam",
+		FoodSource:     "agent",
+		Macros:         models.Macros{Calories: 320.5, Protein: 8, Carbs: 40, Fat: 14.25},
+		Assumptions:    []models.Assumption{{Field: "butter_weight", AssumedValue: 10, Unit: "g"}, {Field: "jam", AssumedValue: 1, Unit: "tbsp"}},
+		ConversationID: &conversationID,
+		CreatedAt:      "2024-03-01T08:01:00Z",
+	}
+
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if err := cw.Write(mealRecord(meal)); err != nil {
+		t.Fatal(err)
+	}
+	cw.Flush()
+
+	want := "m1,2024-03-01T08:00:00Z,breakfast,\"Toast, \"\"extra\"\" butter\nand jam\",agent,320.5,8,40,14.25," +
+		"butter_weight=10 g; jam=1 tbsp,c1,2024-03-01T08:01:00Z\n"
+	if buf.String() != want {
+		t.Errorf("row = %q, want %q", buf.String(), want)
+	}
+
+	// The row reads back to the same fields.
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || !reflect.DeepEqual(records[0], mealRecord(meal)) {
+		t.Errorf("read back %q", records)
+	}
+	if len(records[0]) != len(csvHeader) {
+		t.Errorf("row has %d fields, header has %d", len(records[0]), len(csvHeader))
+	}
+}
+
+func TestMealRecordWithoutConversation(t *testing.T) {
+	record := mealRecord(&models.MealLog{ID: "m1"})
+	if got := record[10]; got != "" {
+		t.Errorf("conversation_id = %q, want empty", got)
+	}
+	if got := record[9]; got != "" {
+		t.Errorf("assumptions = %q, want empty", got)
+	}
+}
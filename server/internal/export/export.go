@@ -0,0 +1,83 @@
+// Package export writes a user's data in portable formats. The writers write
+// as they read, so large histories are streamed instead of held in memory.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// Format is an export format.
+type Format string
+
+const (
+	// FormatCSV is a CSV file of the meal logs.
+	FormatCSV Format = "csv"
+	// FormatJSON is a JSON archive of everything stored about the user.
+	FormatJSON Format = "json"
+	// FormatFHIR is a FHIR R4 Bundle of the meal logs as R5 NutritionIntake
+	// resources.
+	FormatFHIR Format = "fhir"
+)
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatFHIR:
+		return "application/fhir+json"
+	default:
+		return "application/json"
+	}
+}
+
+// FileName returns the name to save an export of the user under.
+func (f Format) FileName(user *models.User, now time.Time) string {
+	extension := string(f)
+	if f == FormatFHIR {
+		extension = "fhir.json"
+	}
+	return fmt.Sprintf("mugo-%s-%s.%s", user.Username, now.Format("2006-01-02"), extension)
+}
+
+// Write writes the user's data to w in the given format. An error may leave
+// w with a partial export.
+func Write(ctx context.Context, w io.Writer, database *db.Database, user *models.User, format Format) error {
+	switch format {
+	case FormatCSV:
+		return WriteCSV(ctx, w, database, user)
+	case FormatJSON:
+		return WriteJSON(ctx, w, database, user)
+	case FormatFHIR:
+		return WriteFHIR(ctx, w, database, user)
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// pageSize is how many rows are read per query from paged lists.
+const pageSize = 500
+
+// eachPage calls fn for every item of a paged list.
+func eachPage[T any](list func(limit, offset int) ([]T, error), fn func(T) error) error {
+	for offset := 0; ; offset += pageSize {
+		items, err := list(pageSize, offset)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+		if len(items) < pageSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package export
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// ucum is the code system of the units of the nutrient amounts.
+const ucum = "http://unitsofmeasure.org"
+
+// The FHIR resources below carry only the elements an export fills in.
+//
+// R4 has no resource for food eaten; NutritionIntake was introduced in R5.
+// The bundle is otherwise R4 but follows the R5 shape of NutritionIntake.
+
+type fhirReference struct {
+	Reference string `json:"reference"`
+}
+
+type fhirCodeableConcept struct {
+	Text string `json:"text"`
+}
+
+type fhirCodeableReference struct {
+	Concept fhirCodeableConcept `json:"concept"`
+}
+
+type fhirQuantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit"`
+	System string  `json:"system"`
+	Code   string  `json:"code"`
+}
+
+type fhirAnnotation struct {
+	Text string `json:"text"`
+}
+
+type fhirIdentifier struct {
+	System string `json:"system"`
+	Value  string `json:"value"`
+}
+
+type fhirPatient struct {
+	ResourceType string           `json:"resourceType"`
+	ID           string           `json:"id"`
+	Identifier   []fhirIdentifier `json:"identifier"`
+}
+
+type fhirConsumedItem struct {
+	Type             fhirCodeableConcept   `json:"type"`
+	NutritionProduct fhirCodeableReference `json:"nutritionProduct"`
+}
+
+type fhirIngredientLabel struct {
+	Nutrient fhirCodeableReference `json:"nutrient"`
+	Amount   fhirQuantity          `json:"amount"`
+}
+
+type fhirNutritionIntake struct {
+	ResourceType       string                `json:"resourceType"`
+	ID                 string                `json:"id"`
+	Status             string                `json:"status"`
+	Code               fhirCodeableConcept   `json:"code"`
+	Subject            fhirReference         `json:"subject"`
+	OccurrenceDateTime string                `json:"occurrenceDateTime"`
+	Recorded           string                `json:"recorded,omitempty"`
+	ConsumedItem       []fhirConsumedItem    `json:"consumedItem"`
+	IngredientLabel    []fhirIngredientLabel `json:"ingredientLabel"`
+	Note               []fhirAnnotation      `json:"note,omitempty"`
+}
+
+type fhirEntry struct {
+	FullURL  string `json:"fullUrl"`
+	Resource any    `json:"resource"`
+}
+
+// WriteFHIR writes the user's meal logs as a FHIR Bundle of type collection:
+// a Patient for the user followed by a NutritionIntake per meal, each
+// referencing the patient.
+func WriteFHIR(ctx context.Context, w io.Writer, database *db.Database, user *models.User) error {
+	patientURL := "urn:uuid:" + user.ID
+
+	s := &jsonStream{w: w}
+	s.raw("{")
+	s.field("resourceType", "Bundle")
+	s.raw(",")
+	s.field("type", "collection")
+	s.raw(",")
+	s.field("timestamp", time.Now().UTC().Format(time.RFC3339))
+
+	s.beginList("entry")
+	patient := fhirPatient{
+		ResourceType: "Patient",
+		ID:           user.ID,
+		Identifier:   []fhirIdentifier{{System: "urn:mugo:username", Value: user.Username}},
+	}
+	s.item(fhirEntry{FullURL: patientURL, Resource: patient})
+
+	for meal, err := range database.MealLogRepository.AllByUser(ctx, user.ID) {
+		if err != nil {
+			return err
+		}
+		s.item(fhirEntry{FullURL: "urn:uuid:" + meal.ID, Resource: nutritionIntake(meal, patientURL)})
+		if s.err != nil {
+			return s.err
+		}
+	}
+	s.endList()
+
+	s.raw("}\n")
+	return s.err
+}
+
+// nutritionIntake converts a meal log to a NutritionIntake resource.
+func nutritionIntake(meal *models.MealLog, patientURL string) fhirNutritionIntake {
+	intake := fhirNutritionIntake{
+		ResourceType:       "NutritionIntake",
+		ID:                 meal.ID,
+		Status:             "completed",
+		Code:               fhirCodeableConcept{Text: meal.MealType},
+		Subject:            fhirReference{Reference: patientURL},
+		OccurrenceDateTime: meal.RecordedAt,
+		Recorded:           meal.CreatedAt,
+		ConsumedItem: []fhirConsumedItem{{
+			Type:             fhirCodeableConcept{Text: "food"},
+			NutritionProduct: fhirCodeableReference{Concept: fhirCodeableConcept{Text: meal.FoodName}},
+		}},
+		IngredientLabel: []fhirIngredientLabel{
+			nutrient("Energy", meal.Macros.Calories, "kcal", "kcal"),
+			nutrient("Protein", meal.Macros.Protein, "g", "g"),
+			nutrient("Carbohydrate", meal.Macros.Carbs, "g", "g"),
+			nutrient("Fat", meal.Macros.Fat, "g", "g"),
+		},
+	}
+	if assumptions := formatAssumptions(meal.Assumptions); assumptions != "" {
+		intake.Note = []fhirAnnotation{{Text: "Assumptions: " + assumptions}}
+	}
+	return intake
+}
+
+func nutrient(name string, value float64, unit, code string) fhirIngredientLabel {
+	return fhirIngredientLabel{
+		Nutrient: fhirCodeableReference{Concept: fhirCodeableConcept{Text: name}},
+		Amount:   fhirQuantity{Value: value, Unit: unit, System: ucum, Code: code},
+	}
+}
@@ -0,0 +1,76 @@
+package export
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+func TestNutritionIntake(t *testing.T) {
+	meal := &models.MealLog{
+		ID:          "m1",
+		RecordedAt:  "2024-03-01T08:00:00Z",
+		MealType:    "breakfast",
+		FoodName:    "Oatmeal",
+		Macros:      models.Macros{Calories: 350, Protein: 12, Carbs: 60, Fat: 6.5},
+		Assumptions: []models.Assumption{{Field: "oats_weight", AssumedValue: 80, Unit: "g", Confidence: "high"}},
+		CreatedAt:   "2024-03-01T08:01:00Z",
+	}
+
+	data, err := json.Marshal(nutritionIntake(meal, "urn:uuid:u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	label := func(name string, value float64, unit string) map[string]any {
+		return map[string]any{
+			"nutrient": map[string]any{"concept": map[string]any{"text": name}},
+			"amount":   map[string]any{"value": value, "unit": unit, "system": "http://unitsofmeasure.org", "code": unit},
+		}
+	}
+	want := map[string]any{
+		"resourceType":       "NutritionIntake",
+		"id":                 "m1",
+		"status":             "completed",
+		"code":               map[string]any{"text": "breakfast"},
+		"subject":            map[string]any{"reference": "urn:uuid:u1"},
+		"occurrenceDateTime": "2024-03-01T08:00:00Z",
+		"recorded":           "2024-03-01T08:01:00Z",
+		"consumedItem": []any{map[string]any{
+			"type":             map[string]any{"text": "food"},
+			"nutritionProduct": map[string]any{"concept": map[string]any{"text": "Oatmeal"}},
+		}},
+		"ingredientLabel": []any{
+			label("Energy", 350, "kcal"),
+			label("Protein", 12, "g"),
+			label("Carbohydrate", 60, "g"),
+			label("Fat", 6.5, "g"),
+		},
+		"note": []any{map[string]any{"text": "Assumptions: oats_weight=80 g (high)"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NutritionIntake =\n%s\nwant\n%v", data, want)
+	}
+}
+
+func TestNutritionIntakeWithoutAssumptions(t *testing.T) {
+	data, err := json.Marshal(nutritionIntake(&models.MealLog{ID: "m1"}, "urn:uuid:u1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"note", "recorded"} {
+		if _, ok := got[field]; ok {
+			t.Errorf("%s is set on a meal without it: %s", field, data)
+		}
+	}
+}
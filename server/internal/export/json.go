@@ -0,0 +1,185 @@
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// archiveVersion is bumped when the layout of the JSON archive changes.
+const archiveVersion = 2
+
+// archiveConversation is a conversation with its messages.
+type archiveConversation struct {
+	*models.Conversation
+	Messages []*models.ConversationMessage `json:"messages"`
+}
+
+// archiveSession is an agent session with its events.
+type archiveSession struct {
+	*models.AgentSession
+	Events []models.AgentEvent `json:"events"`
+}
+
+// WriteJSON writes a JSON archive of everything stored about the user: the
+// profile, meal logs and their revisions, conversations with their messages,
+// agent sessions with their events, nutrition summaries, reports and LLM
+// usage. Each list is written as it is read.
+func WriteJSON(ctx context.Context, w io.Writer, database *db.Database, user *models.User) error {
+	s := &jsonStream{w: w}
+	s.raw("{")
+	s.field("format", "mugo-export")
+	s.raw(",")
+	s.field("version", archiveVersion)
+	s.raw(",")
+	s.field("exported_at", time.Now().UTC().Format(time.RFC3339))
+	s.raw(",")
+	s.field("user", user)
+
+	s.beginList("meal_logs")
+	for meal, err := range database.MealLogRepository.AllByUser(ctx, user.ID) {
+		if err != nil {
+			return err
+		}
+		s.item(meal)
+		if s.err != nil {
+			return s.err
+		}
+	}
+	s.endList()
+
+	err := writePages(s, "meal_revisions", func(limit, offset int) ([]*models.MealRevision, error) {
+		return database.MealLogRepository.ListRevisionsByUser(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+
+	conversations, err := database.ConversationRepository.ListByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	s.beginList("conversations")
+	for _, conversation := range conversations {
+		messages, err := database.ConversationRepository.ListMessages(ctx, conversation.ID)
+		if err != nil {
+			return err
+		}
+		s.item(archiveConversation{Conversation: conversation, Messages: messages})
+	}
+	s.endList()
+
+	sessions, err := database.SessionRepository.ListAllByUser(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	s.beginList("agent_sessions")
+	for _, agentSession := range sessions {
+		events, err := database.SessionRepository.ListEvents(ctx, agentSession.AppName, agentSession.UserID, agentSession.ID)
+		if err != nil {
+			return err
+		}
+		s.item(archiveSession{AgentSession: agentSession, Events: events})
+	}
+	s.endList()
+
+	err = writePages(s, "daily_summaries", func(limit, offset int) ([]*models.DailyNutritionSummary, error) {
+		return database.NutritionRepository.ListDailyByUser(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	err = writePages(s, "weekly_summaries", func(limit, offset int) ([]*models.WeeklyNutritionSummary, error) {
+		return database.NutritionRepository.ListWeeklyByUser(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	err = writePages(s, "reports", func(limit, offset int) ([]*models.Report, error) {
+		return database.ReportRepository.ListByUser(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+	err = writePages(s, "llm_usage", func(limit, offset int) ([]*models.LLMUsageRecord, error) {
+		return database.UsageRepository.ListByUser(ctx, user.ID, limit, offset)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.raw("}\n")
+	return s.err
+}
+
+// jsonStream writes a JSON document piece by piece. The first error is kept
+// and stops all further writes.
+type jsonStream struct {
+	w     io.Writer
+	err   error
+	first bool // no item has been written to the open list yet
+}
+
+func (s *jsonStream) raw(text string) {
+	if s.err == nil {
+		_, s.err = io.WriteString(s.w, text)
+	}
+}
+
+func (s *jsonStream) value(v any) {
+	if s.err != nil {
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.err = fmt.Errorf("failed to encode export: %w", err)
+		return
+	}
+	_, s.err = s.w.Write(data)
+}
+
+func (s *jsonStream) field(name string, v any) {
+	s.value(name)
+	s.raw(":")
+	s.value(v)
+}
+
+// beginList opens the list field name of the enclosing object, which must
+// already have a field.
+func (s *jsonStream) beginList(name string) {
+	s.raw(",")
+	s.value(name)
+	s.raw(":[")
+	s.first = true
+}
+
+func (s *jsonStream) item(v any) {
+	if !s.first {
+		s.raw(",")
+	}
+	s.first = false
+	s.value(v)
+}
+
+func (s *jsonStream) endList() {
+	s.raw("]")
+}
+
+// writePages writes the list field name from a paged list.
+func writePages[T any](s *jsonStream, name string, list func(limit, offset int) ([]T, error)) error {
+	s.beginList(name)
+	err := eachPage(list, func(item T) error {
+		s.item(item)
+		return s.err
+	})
+	if err != nil {
+		return err
+	}
+	s.endList()
+	return s.err
+}
@@ -0,0 +1,191 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// failingWriter accepts n bytes and fails every write after that.
+type failingWriter struct {
+	n       int
+	written bytes.Buffer
+	writes  int
+}
+
+var errWrite = errors.New("connection reset")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.written.Len()+len(p) > w.n {
+		return 0, errWrite
+	}
+	return w.written.Write(p)
+}
+
+func TestJSONStreamLists(t *testing.T) {
+	tests := []struct {
+		name  string
+		lists map[string][]any
+		order []string
+		want  string
+	}{
+		{"empty list", map[string][]any{"meals": nil}, []string{"meals"}, `{"version":1,"meals":[]}`},
+		{"one item", map[string][]any{"meals": {1}}, []string{"meals"}, `{"version":1,"meals":[1]}`},
+		{"several items", map[string][]any{"meals": {1, "two", map[string]int{"n": 3}}}, []string{"meals"},
+			`{"version":1,"meals":[1,"two",{"n":3}]}`},
+		{"empty list after a full one", map[string][]any{"meals": {1, 2}, "reports": nil}, []string{"meals", "reports"},
+			`{"version":1,"meals":[1,2],"reports":[]}`},
+		{"full list after an empty one", map[string][]any{"meals": nil, "reports": {3}}, []string{"meals", "reports"},
+			`{"version":1,"meals":[],"reports":[3]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := &jsonStream{w: &buf}
+			s.raw("{")
+			s.field("version", 1)
+			for _, name := range tt.order {
+				s.beginList(name)
+				for _, item := range tt.lists[name] {
+					s.item(item)
+				}
+				s.endList()
+			}
+			s.raw("}")
+			if s.err != nil {
+				t.Fatal(s.err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("stream = %s, want %s", buf.String(), tt.want)
+			}
+			if !json.Valid(buf.Bytes()) {
+				t.Errorf("stream is not valid JSON: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestJSONStreamKeepsFirstError(t *testing.T) {
+	w := &failingWriter{n: len(`{"version":1,"meals":[`)}
+	s := &jsonStream{w: w}
+	s.raw("{")
+	s.field("version", 1)
+	s.beginList("meals")
+	s.item(1)
+	writes := w.writes
+	s.item(2)
+	s.endList()
+	s.raw("}")
+
+	if !errors.Is(s.err, errWrite) {
+		t.Errorf("error = %v, want the write error", s.err)
+	}
+	if w.writes != writes {
+		t.Errorf("stream wrote %d more times after the error", w.writes-writes)
+	}
+	if got := w.written.String(); got != `{"version":1,"meals":[` {
+		t.Errorf("written = %s", got)
+	}
+}
+
+func TestJSONStreamEncodeError(t *testing.T) {
+	var buf bytes.Buffer
+	s := &jsonStream{w: &buf}
+	s.beginList("meals")
+	s.item(make(chan int))
+	s.item(1)
+	if s.err == nil {
+		t.Fatal("stream accepted a value JSON cannot encode")
+	}
+	if got := buf.String(); got != `,"meals":[` {
+		t.Errorf("written = %s, want nothing after the failed item", got)
+	}
+}
+
+func TestWritePages(t *testing.T) {
+	// pages returns a list of total items served pageSize at a time.
+	pages := func(total int, calls *int) func(limit, offset int) ([]int, error) {
+		return func(limit, offset int) ([]int, error) {
+			*calls++
+			var items []int
+			for i := offset; i < total && i < offset+limit; i++ {
+				items = append(items, i)
+			}
+			return items, nil
+		}
+	}
+
+	tests := []struct {
+		name      string
+		total     int
+		wantCalls int
+	}{
+		{"empty", 0, 1},
+		{"part of a page", 3, 1},
+		{"exactly one page", pageSize, 2},
+		{"more than a page", pageSize + 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			s := &jsonStream{w: &buf}
+			s.raw(`{"version":1`)
+			calls := 0
+			if err := writePages(s, "items", pages(tt.total, &calls)); err != nil {
+				t.Fatal(err)
+			}
+			s.raw("}")
+
+			var got struct {
+				Items []int `json:"items"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if len(got.Items) != tt.total || got.Items == nil {
+				t.Errorf("got %d items, want %d", len(got.Items), tt.total)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("list called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWritePagesListError(t *testing.T) {
+	errList := errors.New("connection lost")
+	var buf bytes.Buffer
+	s := &jsonStream{w: &buf}
+	s.raw(`{"version":1`)
+	err := writePages(s, "items", func(limit, offset int) ([]int, error) {
+		if offset > 0 {
+			return nil, errList
+		}
+		return make([]int, limit), nil
+	})
+	if !errors.Is(err, errList) {
+		t.Errorf("error = %v, want the list error", err)
+	}
+	if bytes.HasSuffix(buf.Bytes(), []byte("]")) {
+		t.Error("the list was closed after the error")
+	}
+}
+
+func TestWritePagesWriteError(t *testing.T) {
+	w := &failingWriter{n: 100}
+	s := &jsonStream{w: w}
+	s.raw(`{"version":1`)
+	calls := 0
+	err := writePages(s, "items", func(limit, offset int) ([]int, error) {
+		calls++
+		return make([]int, limit), nil
+	})
+	if !errors.Is(err, errWrite) {
+		t.Errorf("error = %v, want the write error", err)
+	}
+	if calls != 1 {
+		t.Errorf("list called %d times after the write failed, want 1", calls)
+	}
+}
@@ -12,6 +12,11 @@ import (
 // DoRequest executes an HTTP request and returns the response.
 // It handles request creation, JSON marshaling of body, and basic error wrapping.
 func DoRequest(ctx context.Context, client *http.Client, method, url string, body any) (*http.Response, error) {
+	return DoRequestWithHeaders(ctx, client, method, url, body, nil)
+}
+
+// DoRequestWithHeaders is DoRequest with extra request headers, e.g. Authorization.
+func DoRequestWithHeaders(ctx context.Context, client *http.Client, method, url string, body any, headers http.Header) (*http.Response, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		bodyBytes, err := json.Marshal(body)
@@ -29,6 +34,11 @@ func DoRequest(ctx context.Context, client *http.Client, method, url string, bod
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -0,0 +1,58 @@
+// Package importer imports meal history from the CSV exports of other
+// trackers.
+package importer
+
+import (
+	"context"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/db"
+)
+
+// Result reports what an import did.
+type Result struct {
+	Source     Source     `json:"source"`
+	Rows       int        `json:"rows" doc:"Rows read, excluding the header and blank lines"`
+	Imported   int        `json:"imported" doc:"Rows logged as meals"`
+	Duplicates int        `json:"duplicates" doc:"Rows skipped because an earlier import logged them"`
+	Failed     int        `json:"failed" doc:"Rows that could not be read"`
+	Errors     []RowError `json:"errors" doc:"Why each failed row could not be read"`
+}
+
+// Import logs the rows Parse read from an export of source as the user's
+// meals, with food_source manual_entry, and rebuilds the daily and weekly
+// summaries of the days they fall on. Rows imported before are skipped. The
+// meals and summaries are written in one transaction.
+func Import(ctx context.Context, database *db.Database, userID string, source Source, rows []Row, rowErrors []RowError) (*Result, error) {
+	result := &Result{
+		Source: source,
+		Rows:   len(rows) + len(rowErrors),
+		Failed: len(rowErrors),
+		Errors: rowErrors,
+	}
+	if result.Errors == nil {
+		result.Errors = []RowError{}
+	}
+
+	err := database.WithTx(ctx, func(ctx context.Context, txDB *db.TxDatabase) error {
+		var dates []time.Time
+		for _, row := range rows {
+			meal, err := txDB.MealLogRepository.Import(ctx, userID, string(source), row.Key,
+				row.FoodName, string(row.MealType), row.RecordedAt, row.Macros)
+			if err != nil {
+				return err
+			}
+			if meal == nil {
+				result.Duplicates++
+				continue
+			}
+			result.Imported++
+			dates = append(dates, row.RecordedAt)
+		}
+		return txDB.NutritionRepository.Rebuild(ctx, userID, dates)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
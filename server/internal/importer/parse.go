@@ -0,0 +1,302 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// Source is a tracker whose CSV exports can be imported.
+type Source string
+
+const (
+	// MyFitnessPal is the "Nutrition Summary" export of MyFitnessPal: a row
+	// per meal per day with the meal's totals.
+	MyFitnessPal Source = "myfitnesspal"
+	// Cronometer is the "Food & Recipe Entries" export of Cronometer: a row
+	// per food eaten.
+	Cronometer Source = "cronometer"
+)
+
+// Row is a meal read from an export.
+type Row struct {
+	Line       int
+	FoodName   string
+	MealType   models.MealType
+	RecordedAt time.Time
+	Macros     models.Macros
+	// Key identifies the row across imports of the same export.
+	Key string
+}
+
+// RowError is a row that could not be imported.
+type RowError struct {
+	Row     int    `json:"row" doc:"Line of the row in the file, the header being line 1"`
+	Message string `json:"message"`
+}
+
+// columns names the columns of an export, matched case-insensitively.
+// Optional columns may be missing.
+type columns struct {
+	date, meal, calories string
+	time, food, amount   string
+	protein, carbs, fat  string
+}
+
+var sourceColumns = map[Source]columns{
+	MyFitnessPal: {
+		date:     "date",
+		meal:     "meal",
+		calories: "calories",
+		time:     "time",
+		food:     "note",
+		protein:  "protein (g)",
+		carbs:    "carbohydrates (g)",
+		fat:      "fat (g)",
+	},
+	Cronometer: {
+		date:     "day",
+		meal:     "group",
+		calories: "energy (kcal)",
+		time:     "time",
+		food:     "food name",
+		amount:   "amount",
+		protein:  "protein (g)",
+		carbs:    "carbs (g)",
+		fat:      "fat (g)",
+	},
+}
+
+// defaultHours is when meals without a time are recorded, in the user's
+// time zone.
+var defaultHours = map[models.MealType]int{
+	models.MealTypeBreakfast: 8,
+	models.MealTypeLunch:     12,
+	models.MealTypeSnack:     15,
+	models.MealTypeDinner:    18,
+	models.MealTypeUnknown:   12,
+}
+
+var (
+	dateLayouts = []string{"2006-01-02", "1/2/2006", "01/02/2006"}
+	timeLayouts = []string{"15:04", "15:04:05", "3:04 PM", "3:04:05 PM", "3:04PM"}
+)
+
+// maxFoodName is the length of meal_logs.food_name.
+const maxFoodName = 255
+
+// Parse reads the rows of an export of source. Dates and times without a
+// zone are read in loc. Rows that cannot be read are returned as row errors;
+// an error means the file is not an export of source at all.
+func Parse(source Source, r io.Reader, loc *time.Location) ([]Row, []RowError, error) {
+	cols, ok := sourceColumns[source]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown import source %q", source)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, errors.New("the file is empty")
+		}
+		return nil, nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if _, seen := index[name]; !seen {
+			index[name] = i
+		}
+	}
+	var missing []string
+	for _, required := range []string{cols.date, cols.meal, cols.calories} {
+		if _, ok := index[required]; !ok {
+			missing = append(missing, required)
+		}
+	}
+	if source == Cronometer {
+		if _, ok := index[cols.food]; !ok {
+			missing = append(missing, cols.food)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, nil, fmt.Errorf("not a %s export: missing columns %s", source, strings.Join(missing, ", "))
+	}
+
+	var rows []Row
+	var rowErrors []RowError
+	// Identical rows are all kept, so the occurrence goes into the key.
+	occurrences := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rowErrors = append(rowErrors, RowError{Row: parseErr.StartLine, Message: parseErr.Err.Error()})
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to read row: %w", err)
+		}
+		if isBlank(record) {
+			continue
+		}
+		line, _ := reader.FieldPos(0)
+
+		field := func(name string) string {
+			i, ok := index[name]
+			if name == "" || !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row, err := parseRow(source, field, cols, loc)
+		if err != nil {
+			rowErrors = append(rowErrors, RowError{Row: line, Message: err.Error()})
+			continue
+		}
+		row.Line = line
+		content := strings.Join(record, "\x1f")
+		occurrences[content]++
+		row.Key = rowKey(content, occurrences[content])
+		rows = append(rows, row)
+	}
+	return rows, rowErrors, nil
+}
+
+func parseRow(source Source, field func(string) string, cols columns, loc *time.Location) (Row, error) {
+	var row Row
+
+	day, err := parseDate(field(cols.date), loc)
+	if err != nil {
+		return row, err
+	}
+	row.MealType = mealType(field(cols.meal))
+	hour, minute, second := defaultHours[row.MealType], 0, 0
+	if raw := field(cols.time); raw != "" {
+		t, err := parseTime(raw)
+		if err != nil {
+			return row, err
+		}
+		hour, minute, second = t.Clock()
+	}
+	row.RecordedAt = time.Date(day.Year(), day.Month(), day.Day(), hour, minute, second, 0, loc)
+
+	row.FoodName = field(cols.food)
+	if amount := field(cols.amount); amount != "" && row.FoodName != "" {
+		row.FoodName += " (" + amount + ")"
+	}
+	if row.FoodName == "" {
+		if source == Cronometer {
+			return row, errors.New("food name is empty")
+		}
+		meal := field(cols.meal)
+		if meal == "" {
+			meal = "Meal"
+		}
+		row.FoodName = meal + " from MyFitnessPal"
+	}
+	if len(row.FoodName) > maxFoodName {
+		row.FoodName = strings.ToValidUTF8(row.FoodName[:maxFoodName], "")
+	}
+
+	for _, m := range []struct {
+		column   string
+		value    *float64
+		required bool
+	}{
+		{cols.calories, &row.Macros.Calories, true},
+		{cols.protein, &row.Macros.Protein, false},
+		{cols.carbs, &row.Macros.Carbs, false},
+		{cols.fat, &row.Macros.Fat, false},
+	} {
+		if *m.value, err = parseAmount(m.column, field(m.column), m.required); err != nil {
+			return row, err
+		}
+	}
+	return row, nil
+}
+
+func parseDate(raw string, loc *time.Location) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, errors.New("date is empty")
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, raw, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", raw)
+}
+
+func parseTime(raw string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(raw)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", raw)
+}
+
+// parseAmount parses a nutrient amount. Empty optional amounts are zero.
+func parseAmount(column, raw string, required bool) (float64, error) {
+	if raw == "" {
+		if required {
+			return 0, fmt.Errorf("%s is empty", column)
+		}
+		return 0, nil
+	}
+	v, err := strconv.ParseFloat(strings.ReplaceAll(raw, ",", ""), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", column, raw)
+	}
+	if v < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %s", column, raw)
+	}
+	return v, nil
+}
+
+// mealType maps the meal names of the trackers to meal types. Custom meals
+// are unknown.
+func mealType(meal string) models.MealType {
+	switch strings.ToLower(meal) {
+	case "breakfast":
+		return models.MealTypeBreakfast
+	case "lunch":
+		return models.MealTypeLunch
+	case "dinner":
+		return models.MealTypeDinner
+	case "snack", "snacks":
+		return models.MealTypeSnack
+	default:
+		return models.MealTypeUnknown
+	}
+}
+
+// rowKey hashes the content of a row with its occurrence in the file.
+func rowKey(content string, occurrence int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x1e%d", content, occurrence)))
+	return hex.EncodeToString(sum[:])
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,210 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// newYork is fixed to standard time, so tests do not need the zone database.
+var newYork = time.FixedZone("EST", -5*60*60)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		source Source
+		csv    string
+		want   []Row
+	}{
+		{
+			name:   "MyFitnessPal",
+			source: MyFitnessPal,
+			csv: "\ufeffDate,Meal,Calories,Fat (g),Carbohydrates (g),Protein (g),Note\n" +
+				"2024-03-01,Breakfast,350,12,40,20,Oatmeal\n" +
+				"2024-03-01,Dinner,\"1,200\",50,100,60,\n",
+			want: []Row{
+				{Line: 2, FoodName: "Oatmeal", MealType: models.MealTypeBreakfast,
+					RecordedAt: time.Date(2024, 3, 1, 8, 0, 0, 0, newYork),
+					Macros:     models.Macros{Calories: 350, Protein: 20, Carbs: 40, Fat: 12}},
+				{Line: 3, FoodName: "Dinner from MyFitnessPal", MealType: models.MealTypeDinner,
+					RecordedAt: time.Date(2024, 3, 1, 18, 0, 0, 0, newYork),
+					Macros:     models.Macros{Calories: 1200, Protein: 60, Carbs: 100, Fat: 50}},
+			},
+		},
+		{
+			name:   "Cronometer",
+			source: Cronometer,
+			csv: "Day,Time,Group,Food Name,Amount,Energy (kcal),Protein (g),Carbs (g),Fat (g)\n" +
+				"3/2/2024,7:30 am,Breakfast,Banana,1 medium,105,1.3,27,0.4\n" +
+				"03/02/2024,13:15:30,Lunch,Rice,,200,4,45,\n" +
+				"2024-03-02,,Snacks,Almonds,28 g,164,6,6,14\n" +
+				"2024-03-02,,Supper,Soup,1 bowl,150,,,\n",
+			want: []Row{
+				{Line: 2, FoodName: "Banana (1 medium)", MealType: models.MealTypeBreakfast,
+					RecordedAt: time.Date(2024, 3, 2, 7, 30, 0, 0, newYork),
+					Macros:     models.Macros{Calories: 105, Protein: 1.3, Carbs: 27, Fat: 0.4}},
+				{Line: 3, FoodName: "Rice", MealType: models.MealTypeLunch,
+					RecordedAt: time.Date(2024, 3, 2, 13, 15, 30, 0, newYork),
+					Macros:     models.Macros{Calories: 200, Protein: 4, Carbs: 45}},
+				{Line: 4, FoodName: "Almonds (28 g)", MealType: models.MealTypeSnack,
+					RecordedAt: time.Date(2024, 3, 2, 15, 0, 0, 0, newYork),
+					Macros:     models.Macros{Calories: 164, Protein: 6, Carbs: 6, Fat: 14}},
+				{Line: 5, FoodName: "Soup (1 bowl)", MealType: models.MealTypeUnknown,
+					RecordedAt: time.Date(2024, 3, 2, 12, 0, 0, 0, newYork),
+					Macros:     models.Macros{Calories: 150}},
+			},
+		},
+		{
+			name:   "header case, spacing and blank rows",
+			source: MyFitnessPal,
+			csv:    " DATE , meal ,CALORIES\n\n2024-03-01,Lunch,500\n,,\n",
+			want: []Row{
+				{Line: 3, FoodName: "Lunch from MyFitnessPal", MealType: models.MealTypeLunch,
+					RecordedAt: time.Date(2024, 3, 1, 12, 0, 0, 0, newYork),
+					Macros:     models.Macros{Calories: 500}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, rowErrors, err := Parse(tt.source, strings.NewReader(tt.csv), newYork)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rowErrors) > 0 {
+				t.Errorf("row errors = %+v", rowErrors)
+			}
+			if len(rows) != len(tt.want) {
+				t.Fatalf("got %d rows, want %d: %+v", len(rows), len(tt.want), rows)
+			}
+			for i, want := range tt.want {
+				got := rows[i]
+				if got.Key == "" {
+					t.Errorf("row %d has no key", i)
+				}
+				got.Key = ""
+				if got.Line != want.Line || got.FoodName != want.FoodName || got.MealType != want.MealType ||
+					!got.RecordedAt.Equal(want.RecordedAt) || got.Macros != want.Macros {
+					t.Errorf("row %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseNotAnExport(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  Source
+		csv     string
+		wantErr string
+	}{
+		{"empty", MyFitnessPal, "", "the file is empty"},
+		{"missing calories", MyFitnessPal, "Date,Meal,Note\n2024-03-01,Lunch,Soup\n", "missing columns calories"},
+		{"missing food name", Cronometer, "Day,Group,Energy (kcal)\n2024-03-01,Lunch,100\n", "missing columns food name"},
+		{"other export", Cronometer, "Date,Meal,Calories\n", "missing columns day, group, energy (kcal), food name"},
+		{"unknown source", Source("loseit"), "Date\n", `unknown import source "loseit"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse(tt.source, strings.NewReader(tt.csv), time.UTC)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRowErrors(t *testing.T) {
+	csv := "Day,Time,Group,Food Name,Energy (kcal),Protein (g)\n" +
+		"2024-03-02,,Lunch,Soup,150,8\n" +
+		"2024-13-40,,Lunch,Soup,150,8\n" +
+		"2024-03-02,25:99,Lunch,Soup,150,8\n" +
+		"2024-03-02,,Lunch,Soup,-5,8\n" +
+		"2024-03-02,,Lunch,Soup,150,lots\n" +
+		"2024-03-02,,Lunch,,150,8\n" +
+		"2024-03-02,,Lunch,Soup,,8\n" +
+		"2024-03-02,,Lunch,So\"up,150,8\n" +
+		"\"2024-03-02\",,Lunch,\"Stew\nwith beans\",150,8\n" +
+		",,Lunch,Soup,150,8\n"
+
+	rows, rowErrors, err := Parse(Cronometer, strings.NewReader(csv), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].Line != 2 || rows[1].Line != 10 || rows[1].FoodName != "Stew\nwith beans" {
+		t.Errorf("rows = %+v, want the rows on lines 2 and 10", rows)
+	}
+
+	want := []RowError{
+		{3, `invalid date "2024-13-40"`},
+		{4, `invalid time "25:99"`},
+		{5, "energy (kcal) must not be negative, got -5"},
+		{6, `invalid protein (g) "lots"`},
+		{7, "food name is empty"},
+		{8, "energy (kcal) is empty"},
+		{9, `bare " in non-quoted-field`},
+		{12, "date is empty"},
+	}
+	if len(rowErrors) != len(want) {
+		t.Fatalf("row errors = %+v, want %+v", rowErrors, want)
+	}
+	for i := range want {
+		if rowErrors[i] != want[i] {
+			t.Errorf("row error %d = %+v, want %+v", i, rowErrors[i], want[i])
+		}
+	}
+}
+
+func TestParseKeys(t *testing.T) {
+	csv := "Date,Meal,Calories\n" +
+		"2024-03-01,Snacks,100\n" +
+		"2024-03-01,Snacks,100\n" +
+		"2024-03-01,Lunch,500\n"
+
+	parse := func() []Row {
+		rows, rowErrors, err := Parse(MyFitnessPal, strings.NewReader(csv), time.UTC)
+		if err != nil || len(rowErrors) > 0 {
+			t.Fatalf("Parse = %v, %v", rowErrors, err)
+		}
+		return rows
+	}
+	first, second := parse(), parse()
+
+	seen := map[string]bool{}
+	for i, row := range first {
+		if seen[row.Key] {
+			t.Errorf("row %d repeats key %s", i, row.Key)
+		}
+		seen[row.Key] = true
+		if second[i].Key != row.Key {
+			t.Errorf("row %d key changed between imports: %s, %s", i, row.Key, second[i].Key)
+		}
+	}
+
+	// Keys depend on the content, not on where the row is in the file.
+	moved, _, err := Parse(MyFitnessPal, strings.NewReader("Date,Meal,Calories\n2024-03-01,Lunch,500\n"), time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved[0].Key != first[2].Key {
+		t.Errorf("key of a moved row = %s, want %s", moved[0].Key, first[2].Key)
+	}
+}
+
+func TestParseTruncatesFoodName(t *testing.T) {
+	// The cut at maxFoodName bytes falls inside the two-byte "é".
+	name := strings.Repeat("a", maxFoodName-1) + "é au lait"
+	csv := "Day,Group,Food Name,Energy (kcal)\n2024-03-02,Breakfast," + name + ",90\n"
+
+	rows, rowErrors, err := Parse(Cronometer, strings.NewReader(csv), time.UTC)
+	if err != nil || len(rowErrors) > 0 {
+		t.Fatalf("Parse = %v, %v", rowErrors, err)
+	}
+	want := strings.Repeat("a", maxFoodName-1)
+	if rows[0].FoodName != want {
+		t.Errorf("food name = %q (%d bytes), want %d bytes of a", rows[0].FoodName, len(rows[0].FoodName), len(want))
+	}
+}
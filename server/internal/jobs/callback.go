@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrCallbackForbidden means a callback URL points somewhere the server must
+// not send requests to.
+var ErrCallbackForbidden = errors.New("callback URL not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range, which is not routable on
+// the internet either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// CheckCallbackURL reports whether the job runner may POST to rawURL. With
+// allowedHosts, its host must be one of them. The address it resolves to is
+// checked when the callback is sent.
+func CheckCallbackURL(rawURL string, allowedHosts []string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCallbackForbidden, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrCallbackForbidden, u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("%w: no host", ErrCallbackForbidden)
+	}
+	if len(allowedHosts) > 0 && !hostAllowed(u.Hostname(), allowedHosts) {
+		return fmt.Errorf("%w: host %s is not allowed", ErrCallbackForbidden, u.Hostname())
+	}
+	return nil
+}
+
+// newCallbackClient returns the client that sends job callbacks. Callback
+// URLs come from clients, so it only connects to public addresses, except
+// for the allowed hosts, and it does not follow redirects, which could lead
+// anywhere. The address is checked after DNS resolution, so a host name
+// resolving to a private address is refused too.
+func newCallbackClient(allowedHosts []string) *http.Client {
+	publicDialer := &net.Dialer{Timeout: 5 * time.Second, Control: dialPublicOnly}
+	allowedDialer := &net.Dialer{Timeout: 5 * time.Second}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would connect to the callback on the server's behalf.
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err == nil && hostAllowed(host, allowedHosts) {
+			return allowedDialer.DialContext(ctx, network, addr)
+		}
+		return publicDialer.DialContext(ctx, network, addr)
+	}
+
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialPublicOnly refuses connections to loopback, private, link-local and
+// other non-public addresses, such as the cloud metadata service.
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCallbackForbidden, err)
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCallbackForbidden, err)
+	}
+	if !isPublic(ip) {
+		return fmt.Errorf("%w: address %s is not public", ErrCallbackForbidden, ip)
+	}
+	return nil
+}
+
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() &&
+		!ip.IsPrivate() &&
+		!ip.IsLoopback() &&
+		!ip.IsLinkLocalUnicast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+func hostAllowed(host string, allowedHosts []string) bool {
+	return slices.ContainsFunc(allowedHosts, func(allowed string) bool {
+		return strings.EqualFold(allowed, host)
+	})
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.0.0.5", false},
+		{"172.16.3.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("isPublic(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCallbackURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		allowed []string
+		wantErr bool
+	}{
+		{"https://example.com/hook", nil, false},
+		{"ftp://example.com/hook", nil, true},
+		{"https:///hook", nil, true},
+		{"https://example.com/hook", []string{"Example.com"}, false},
+		{"https://evil.example/hook", []string{"example.com"}, true},
+	}
+	for _, tt := range tests {
+		err := CheckCallbackURL(tt.url, tt.allowed)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckCallbackURL(%q, %v) = %v, want error %v", tt.url, tt.allowed, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrCallbackForbidden) {
+			t.Errorf("CheckCallbackURL(%q) error = %v, want ErrCallbackForbidden", tt.url, err)
+		}
+	}
+}
+
+func TestCallbackClientRefusesLoopback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("callback reached a loopback server")
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, nil)
+	_, err := newCallbackClient(nil).Do(req)
+	if !errors.Is(err, ErrCallbackForbidden) {
+		t.Fatalf("Do error = %v, want ErrCallbackForbidden", err)
+	}
+}
+
+func TestCallbackClientDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hook" {
+			http.Redirect(w, r, "/elsewhere", http.StatusTemporaryRedirect)
+			return
+		}
+		t.Error("callback followed a redirect")
+	}))
+	defer server.Close()
+
+	// The test server is on loopback, so it has to be allowed explicitly.
+	u, _ := url.Parse(server.URL)
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL+"/hook", nil)
+	resp, err := newCallbackClient([]string{u.Hostname()}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusTemporaryRedirect)
+	}
+}
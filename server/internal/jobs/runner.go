@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/httputil"
+	"github.com/simhozebs/mugo/internal/logging"
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+// Handler runs one job and returns its result, which is stored as JSON.
+type Handler func(ctx context.Context, job *models.Job) (any, error)
+
+// Runner runs queued jobs with a pool of worker goroutines. Workers claim jobs
+// with FOR UPDATE SKIP LOCKED, so several API processes can share the queue.
+type Runner struct {
+	database      *db.Database
+	handlers      map[models.JobKind]Handler
+	workers       int
+	pollInterval  time.Duration
+	jobTimeout    time.Duration
+	callbackHosts []string
+	httpClient    *http.Client
+	wg            sync.WaitGroup
+}
+
+// NewRunner creates a job runner with the configured worker settings.
+func NewRunner(database *db.Database, cfg config.JobsConfig) *Runner {
+	return &Runner{
+		database:      database,
+		handlers:      make(map[models.JobKind]Handler),
+		workers:       cfg.Workers,
+		pollInterval:  cfg.PollInterval,
+		jobTimeout:    cfg.Timeout,
+		callbackHosts: cfg.CallbackHosts,
+		httpClient:    newCallbackClient(cfg.CallbackHosts),
+	}
+}
+
+// Handle registers the handler of a job kind. It must be called before Start.
+func (r *Runner) Handle(kind models.JobKind, handler Handler) {
+	r.handlers[kind] = handler
+}
+
+// Start starts the workers. They stop claiming jobs once ctx is done; jobs
+// that are already running are finished, see Wait.
+func (r *Runner) Start(ctx context.Context) {
+	for i := 0; i < r.workers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.work(ctx)
+		}()
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.requeueStale(ctx)
+	}()
+
+	slog.Info("Job runner started", "workers", r.workers)
+}
+
+// Wait blocks until every worker has stopped after the context passed to
+// Start is done, or until ctx is done. Jobs still running then are requeued
+// by the stale job check of another runner once they time out.
+func (r *Runner) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) work(ctx context.Context) {
+	for {
+		job, err := r.database.JobRepository.ClaimNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.Warn("Failed to claim job", "error", err)
+		}
+		if job != nil {
+			r.run(ctx, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// run runs a claimed job and records its outcome. The job is not cut short
+// when ctx is done, only by the job timeout.
+func (r *Runner) run(ctx context.Context, job *models.Job) {
+	logger := slog.Default().With("job_id", job.ID, "job_kind", job.Kind)
+	jobCtx, cancel := context.WithTimeout(context.WithoutCancel(logging.WithLogger(ctx, logger)), r.jobTimeout)
+	defer cancel()
+
+	var result any
+	var err error
+	if handler, ok := r.handlers[job.Kind]; ok {
+		result, err = handler(jobCtx, job)
+	} else {
+		err = fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+
+	// Record the outcome even if the job ran out of time.
+	saveCtx := context.WithoutCancel(ctx)
+	var done *models.Job
+	if err != nil {
+		logger.Warn("Job attempt failed", "attempt", job.Attempts, "max_attempts", job.MaxAttempts, "error", err)
+		done, err = r.database.JobRepository.Fail(saveCtx, job.ID, err.Error(), time.Now().Add(retryDelay(job.Attempts)))
+	} else {
+		done, err = r.database.JobRepository.Complete(saveCtx, job.ID, result)
+	}
+	if err != nil {
+		logger.Error("Failed to record job outcome", "error", err)
+		return
+	}
+
+	if done.IsDone() && done.CallbackURL != nil {
+		r.notify(saveCtx, logger, done)
+	}
+}
+
+// notify POSTs the finished job to its callback URL, trying a few times.
+func (r *Runner) notify(ctx context.Context, logger *slog.Logger, job *models.Job) {
+	// The allowed hosts may have changed since the job was enqueued.
+	if err := CheckCallbackURL(*job.CallbackURL, r.callbackHosts); err != nil {
+		logger.Warn("Job callback refused", "error", err)
+		return
+	}
+
+	const attempts = 3
+	for attempt := 1; attempt <= attempts; attempt++ {
+		resp, err := httputil.DoRequest(ctx, r.httpClient, http.MethodPost, *job.CallbackURL, job)
+		if err == nil {
+			err = httputil.CheckStatus(resp, http.StatusOK, http.StatusAccepted, http.StatusNoContent)
+			resp.Body.Close()
+		}
+		if err == nil {
+			return
+		}
+		if errors.Is(err, ErrCallbackForbidden) {
+			logger.Warn("Job callback refused", "error", err)
+			return
+		}
+		logger.Warn("Job callback failed", "attempt", attempt, "max_attempts", attempts, "error", err)
+		if attempt < attempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+}
+
+// requeueStale periodically returns jobs whose worker died to the queue.
+func (r *Runner) requeueStale(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// A job still running past its timeout has lost its worker.
+		count, err := r.database.JobRepository.RequeueStale(ctx, time.Now().Add(-r.jobTimeout-time.Minute))
+		if err != nil {
+			slog.Warn("Failed to requeue stale jobs", "error", err)
+		} else if count > 0 {
+			slog.Info("Requeued stale jobs", "count", count)
+		}
+	}
+}
+
+// retryDelay returns how long a job waits before its next attempt.
+func retryDelay(attempts int) time.Duration {
+	return time.Duration(1<<min(attempts-1, 6)) * 10 * time.Second
+}
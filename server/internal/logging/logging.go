@@ -0,0 +1,64 @@
+// Package logging sets up structured logging with log/slog and carries
+// request-scoped loggers through contexts.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/simhozebs/mugo/internal/config"
+)
+
+// Setup configures the default slog logger. Messages written with the
+// standard log package, e.g. by dependencies, go through it too.
+func Setup(cfg config.LoggingConfig) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+
+	switch mode := cfg.UserContent; mode {
+	case UserContentFull, UserContentTruncate, UserContentRedact:
+		userContent = mode
+	default:
+		return fmt.Errorf("unknown user content log mode %q", mode)
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch format := cfg.Format; format {
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// Fatal logs an error and exits.
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a context carrying the logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the context, e.g. the request logger set
+// by Middleware, or the default logger if there is none.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
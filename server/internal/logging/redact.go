@@ -0,0 +1,39 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"unicode/utf8"
+)
+
+// How much of users' free text is logged, see config.LoggingConfig.
+const (
+	UserContentFull     = "full"
+	UserContentTruncate = "truncate"
+	UserContentRedact   = "redact"
+)
+
+// truncateLength is how many characters of user text the truncate mode keeps.
+const truncateLength = 24
+
+var userContent = UserContentRedact
+
+// UserText is free text a user wrote, such as a meal description. Log it as
+// UserText rather than a string so it is redacted as configured.
+type UserText string
+
+// LogValue implements slog.LogValuer.
+func (t UserText) LogValue() slog.Value {
+	text := string(t)
+	switch userContent {
+	case UserContentFull:
+		return slog.StringValue(text)
+	case UserContentTruncate:
+		if utf8.RuneCountInString(text) <= truncateLength {
+			return slog.StringValue(text)
+		}
+		return slog.StringValue(string([]rune(text)[:truncateLength]) + "…")
+	default:
+		return slog.StringValue(fmt.Sprintf("[redacted %d chars]", utf8.RuneCountInString(text)))
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IdempotencyKey is a client-supplied key and the request it was first used for.
+// Response is nil while that request is still being processed.
+type IdempotencyKey struct {
+	Operation   string          `json:"operation"`
+	UserID      string          `json:"user_id"`
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"`
+	Response    json.RawMessage `json:"response,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
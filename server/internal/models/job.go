@@ -0,0 +1,44 @@
+package models
+
+import "encoding/json"
+
+// JobKind selects the handler that runs a job.
+type JobKind string
+
+const (
+	JobKindNutritionEstimate JobKind = "nutrition_estimate"
+	JobKindUserErasure       JobKind = "user_erasure"
+)
+
+// JobStatus is the state of a job in the queue.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// Job is a unit of background work. Result holds the handler output once the
+// job succeeded, and Error the last failure.
+type Job struct {
+	ID          string          `json:"id"`
+	Kind        JobKind         `json:"kind"`
+	UserID      string          `json:"user_id"`
+	Status      JobStatus       `json:"status"`
+	Payload     json.RawMessage `json:"payload"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       *string         `json:"error,omitempty"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	CallbackURL *string         `json:"callback_url,omitempty"`
+	CreatedAt   string          `json:"created_at"`
+	UpdatedAt   string          `json:"updated_at"`
+	CompletedAt *string         `json:"completed_at,omitempty"`
+}
+
+// IsDone reports whether the job reached a final status.
+func (j *Job) IsDone() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type MealLog struct {
 	ID             string       `json:"id"`
 	UserID         string       `json:"user_id"`
@@ -13,3 +15,50 @@ type MealLog struct {
 	RawResponse    interface{}  `json:"raw_response,omitempty"`
 	CreatedAt      string       `json:"created_at"`
 }
+
+// MealSearchFilter narrows a meal search. Zero values do not filter.
+type MealSearchFilter struct {
+	Query          string
+	MealType       string
+	FoodSource     string
+	RecordedFrom   time.Time
+	RecordedBefore time.Time
+	MinCalories    *float64
+	MaxCalories    *float64
+}
+
+// MealSearchResult is a meal matching a search, with its rank; higher ranks
+// match better.
+type MealSearchResult struct {
+	Meal *MealLog `json:"meal"`
+	Rank float64  `json:"rank"`
+}
+
+// FrequentMeal is a food a user logs often. LatestMealID is the most recent
+// meal with the food, which can be duplicated to log it again.
+type FrequentMeal struct {
+	FoodName      string `json:"food_name"`
+	LatestMealID  string `json:"latest_meal_id"`
+	UsualMealType string `json:"usual_meal_type" doc:"Meal type the food is logged as most often"`
+	TimesLogged   int    `json:"times_logged"`
+	LastLoggedAt  string `json:"last_logged_at"`
+	AverageMacros Macros `json:"average_macros"`
+}
+
+// MealRevision is an edit of a meal log made without the agent. Kind says
+// what the edit was; scale revisions multiply the portion by Factor.
+type MealRevision struct {
+	ID                  string       `json:"id"`
+	MealID              string       `json:"meal_id"`
+	UserID              string       `json:"user_id"`
+	Kind                string       `json:"kind" enum:"scale"`
+	Factor              float64      `json:"factor"`
+	PreviousMacros      Macros       `json:"previous_macros"`
+	PreviousAssumptions []Assumption `json:"previous_assumptions"`
+	Macros              Macros       `json:"macros"`
+	Assumptions         []Assumption `json:"assumptions"`
+	CreatedAt           string       `json:"created_at"`
+}
+
+// MealRevisionKindScale is a revision that scaled a meal's portion.
+const MealRevisionKindScale = "scale"
@@ -12,10 +12,27 @@ const (
 
 // NutritionPayload is the structured response from the nutrition agent.
 type NutritionPayload struct {
-	Name        string       `json:"name"`
-	MealType    MealType     `json:"meal_type"`
-	Macros      Macros       `json:"macros"`
-	Assumptions []Assumption `json:"assumptions"`
+	Name             string            `json:"name"`
+	MealType         MealType          `json:"meal_type"`
+	Macros           Macros            `json:"macros"`
+	Assumptions      []Assumption      `json:"assumptions"`
+	AllergenWarnings []AllergenWarning `json:"allergen_warnings,omitempty"`
+	Meta             *EstimateMeta     `json:"meta,omitempty"`
+}
+
+// EstimateMeta records which agent definition and model produced an estimate.
+type EstimateMeta struct {
+	AgentVersion  string `json:"agent_version"`
+	SchemaVersion string `json:"schema_version,omitempty"`
+	Model         string `json:"model"`
+}
+
+// AllergenWarning flags an ingredient that may contain one of the user's allergens.
+type AllergenWarning struct {
+	Allergen   string `json:"allergen"`
+	Ingredient string `json:"ingredient"`
+	Risk       string `json:"risk,omitempty"`
+	Rationale  string `json:"rationale,omitempty"`
 }
 
 // Assumption represents an assumption made during nutritional analysis.
@@ -27,6 +44,9 @@ type Assumption struct {
 	Unit         string  `json:"unit,omitempty"`
 	Confidence   string  `json:"confidence,omitempty"`
 	Rationale    string  `json:"rationale,omitempty"`
+	// Weight is filled in when a response renders a volume of a food whose
+	// density is known.
+	Weight *Measurement `json:"weight,omitempty" doc:"Approximate weight of the assumed volume"`
 }
 
 // Macros represents the macronutrient values.
@@ -36,3 +56,13 @@ type Macros struct {
 	Carbs    float64 `json:"carbs"`
 	Fat      float64 `json:"fat"`
 }
+
+// Scale returns the macros of factor times the portion.
+func (m Macros) Scale(factor float64) Macros {
+	return Macros{
+		Calories: m.Calories * factor,
+		Protein:  m.Protein * factor,
+		Carbs:    m.Carbs * factor,
+		Fat:      m.Fat * factor,
+	}
+}
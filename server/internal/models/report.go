@@ -0,0 +1,17 @@
+package models
+
+type ReportType string
+
+const (
+	ReportTypeWeeklyCoaching ReportType = "weekly_coaching"
+)
+
+type Report struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	ReportType  ReportType `json:"report_type"`
+	PeriodStart string     `json:"period_start"`
+	PeriodEnd   string     `json:"period_end"`
+	Content     string     `json:"content"`
+	CreatedAt   string     `json:"created_at"`
+}
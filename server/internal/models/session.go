@@ -0,0 +1,28 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AgentSession is a stored ADK session. When its user is a users row, it is
+// linked to the conversation whose session_id is the session ID.
+type AgentSession struct {
+	AppName        string         `json:"app_name"`
+	UserID         string         `json:"user_id"`
+	ID             string         `json:"id"`
+	ConversationID *string        `json:"conversation_id,omitempty"`
+	State          map[string]any `json:"state"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+// AgentEvent is a stored ADK session event. Event holds the whole event,
+// state delta included, as JSON.
+type AgentEvent struct {
+	ID           string          `json:"id"`
+	InvocationID string          `json:"invocation_id"`
+	Author       string          `json:"author"`
+	Event        json.RawMessage `json:"event"`
+	Timestamp    time.Time       `json:"timestamp"`
+}
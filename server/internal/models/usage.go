@@ -0,0 +1,58 @@
+package models
+
+// LLMUsage is the token usage of one LLM call made during an agent invocation.
+type LLMUsage struct {
+	UserID           string
+	AppName          string
+	SessionID        string
+	InvocationID     string
+	Model            string
+	PromptTokens     int
+	CandidatesTokens int
+	TotalTokens      int
+	// EstimatedCostUSD is nil when the model has no configured price.
+	EstimatedCostUSD *float64
+}
+
+// LLMUsageRecord is a stored LLM usage row.
+type LLMUsageRecord struct {
+	ID               int64    `json:"id"`
+	AppName          string   `json:"app_name"`
+	SessionID        string   `json:"session_id"`
+	InvocationID     string   `json:"invocation_id"`
+	Model            string   `json:"model"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CandidatesTokens int      `json:"candidates_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd"`
+	MealLogID        *string  `json:"meal_log_id"`
+	CreatedAt        string   `json:"created_at"`
+}
+
+// UsageTotals sums the LLM usage of a user over some period.
+type UsageTotals struct {
+	Invocations      int     `json:"invocations" doc:"Agent invocations"`
+	LLMCalls         int     `json:"llm_calls" doc:"LLM calls made by the invocations"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CandidatesTokens int64   `json:"candidates_tokens"`
+	TotalTokens      int64   `json:"total_tokens"`
+	EstimatedCostUSD float64 `json:"estimated_cost_usd" doc:"Estimated cost of the priced calls"`
+	UnpricedCalls    int     `json:"unpriced_calls" doc:"Calls to models without a configured price, left out of the cost"`
+}
+
+// Add adds other to the totals.
+func (t *UsageTotals) Add(other UsageTotals) {
+	t.Invocations += other.Invocations
+	t.LLMCalls += other.LLMCalls
+	t.PromptTokens += other.PromptTokens
+	t.CandidatesTokens += other.CandidatesTokens
+	t.TotalTokens += other.TotalTokens
+	t.EstimatedCostUSD += other.EstimatedCostUSD
+	t.UnpricedCalls += other.UnpricedCalls
+}
+
+// DailyUsage is a user's LLM usage on one UTC day.
+type DailyUsage struct {
+	Date string `json:"date" example:"2025-01-07"`
+	UsageTotals
+}
@@ -1,9 +1,131 @@
 package models
 
+import "strings"
+
 type User struct {
 	ID        string                 `json:"id"`
 	Username  string                 `json:"username"`
 	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+	Plan      string                 `json:"plan,omitempty"`
+	Profile   *UserProfile           `json:"profile,omitempty" doc:"Unit system, weight and height, rendered in the unit system"`
 	CreatedAt string                 `json:"created_at"`
 	UpdatedAt string                 `json:"updated_at"`
 }
+
+// Session state keys the macro estimator instruction reads dietary context from.
+// The "user:" prefix shares them across all of a user's sessions.
+const (
+	StateKeyDietaryPreferences = "user:dietary_preferences"
+	StateKeyAllergens          = "user:allergens"
+)
+
+// Metadata keys the dietary profile is stored under in User.Metadata.
+const (
+	MetadataKeyDietaryPreferences = "dietary_preferences"
+	MetadataKeyAllergens          = "allergens"
+)
+
+// Metadata keys the body profile is stored under in User.Metadata. Weight and
+// height are stored in metric whatever the user reads them in.
+const (
+	MetadataKeyUnitSystem = "unit_system"
+	MetadataKeyWeightKg   = "weight_kg"
+	MetadataKeyHeightCm   = "height_cm"
+)
+
+// BodyProfile is the unit system a user reads amounts in and their weight and
+// height, as stored. Weight and height are nil when unknown.
+type BodyProfile struct {
+	UnitSystem string
+	WeightKg   *float64
+	HeightCm   *float64
+}
+
+// BodyProfileFromMetadata reads the body profile stored in user metadata.
+// The unit system defaults to metric.
+func BodyProfileFromMetadata(metadata map[string]interface{}) BodyProfile {
+	profile := BodyProfile{UnitSystem: "metric"}
+	if system, ok := metadata[MetadataKeyUnitSystem].(string); ok && system == "imperial" {
+		profile.UnitSystem = system
+	}
+	if weight, ok := metadata[MetadataKeyWeightKg].(float64); ok {
+		profile.WeightKg = &weight
+	}
+	if height, ok := metadata[MetadataKeyHeightCm].(float64); ok {
+		profile.HeightCm = &height
+	}
+	return profile
+}
+
+// UserProfile is a body profile rendered in the user's unit system.
+type UserProfile struct {
+	UnitSystem string       `json:"unit_system" enum:"metric,imperial"`
+	Weight     *Measurement `json:"weight,omitempty"`
+	Height     *Measurement `json:"height,omitempty"`
+}
+
+// Measurement is an amount rendered for display.
+type Measurement struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+	Text  string  `json:"text" example:"5 ft 9 in" doc:"The amount as the app shows it"`
+}
+
+// DietaryProfile holds the preferences and allergens estimates should respect.
+type DietaryProfile struct {
+	Preferences []string `json:"preferences" example:"[\"vegan\"]" doc:"Dietary preferences, e.g. vegan, halal, low sodium"`
+	Allergens   []string `json:"allergens" example:"[\"peanuts\"]" doc:"Ingredients the user is allergic or intolerant to"`
+}
+
+// DietaryProfileFromMetadata reads the dietary profile stored in user metadata.
+func DietaryProfileFromMetadata(metadata map[string]interface{}) DietaryProfile {
+	return DietaryProfile{
+		Preferences: stringSlice(metadata[MetadataKeyDietaryPreferences]),
+		Allergens:   stringSlice(metadata[MetadataKeyAllergens]),
+	}
+}
+
+// IsEmpty reports whether the profile has neither preferences nor allergens.
+func (p DietaryProfile) IsEmpty() bool {
+	return len(p.Preferences) == 0 && len(p.Allergens) == 0
+}
+
+// SessionState renders the profile as ADK session state for instruction templating.
+func (p DietaryProfile) SessionState() map[string]any {
+	return map[string]any{
+		StateKeyDietaryPreferences: joinOrNone(p.Preferences),
+		StateKeyAllergens:          joinOrNone(p.Allergens),
+	}
+}
+
+func stringSlice(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok && s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func joinOrNone(items []string) string {
+	if len(items) == 0 {
+		return "none"
+	}
+	return strings.Join(items, ", ")
+}
+
+// UserErasure is the tombstone left when a user's data is erased. Erased
+// counts the deleted rows per table.
+type UserErasure struct {
+	ID                 string           `json:"id"`
+	UserID             string           `json:"user_id"`
+	JobID              *string          `json:"job_id,omitempty"`
+	Erased             map[string]int64 `json:"erased"`
+	ADKSessionsDeleted int              `json:"adk_sessions_deleted" doc:"Sessions deleted from the agent runner"`
+	ErasedAt           string           `json:"erased_at"`
+}
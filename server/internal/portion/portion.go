@@ -0,0 +1,68 @@
+// Package portion rescales a meal estimate to a different portion without
+// asking the agent again.
+package portion
+
+import (
+	"math"
+	"strings"
+
+	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/units"
+)
+
+// portionFields are words in the field of an assumption about how much was
+// eaten.
+var portionFields = []string{"portion", "serving", "amount", "quantity", "weight", "volume", "size"}
+
+// Scale returns the macros and assumptions of a meal factor times the
+// portion. Assumptions about amounts have their assumed values scaled too;
+// the others, such as the cooking method, are kept as they are.
+func Scale(macros models.Macros, assumptions []models.Assumption, factor float64) (models.Macros, []models.Assumption) {
+	scaled := make([]models.Assumption, len(assumptions))
+	for i, a := range assumptions {
+		scaled[i] = scaleAssumption(a, factor)
+	}
+	return macros.Scale(factor), scaled
+}
+
+// scaleAssumption scales an assumed amount. Amounts in units of mass and
+// volume are rounded to the precision of their unit. Spoons and cups are
+// rounded to kitchen measures and move to the measure that reads best, so
+// half of 1/4 cup is 2 tbsp. An amount in another unit, such as slices, is
+// scaled as is if the assumption is about the portion.
+func scaleAssumption(a models.Assumption, factor float64) models.Assumption {
+	u, token, rest, known := units.Parse(a.Unit)
+	if !known || u.Dimension == units.Length {
+		if isPortion(a) {
+			a.AssumedValue = math.Round(a.AssumedValue*factor*100) / 100
+		}
+		return a
+	}
+
+	q := units.Quantity{Value: a.AssumedValue * factor, Unit: u}
+	if u.IsKitchenMeasure() {
+		q, _ = q.Kitchen()
+	} else {
+		q = q.Round()
+	}
+	if q.Unit != u {
+		token = q.Unit.Name
+	}
+
+	a.AssumedValue = q.Value
+	a.Unit = strings.TrimSpace(token + " " + rest)
+	return a
+}
+
+func isPortion(a models.Assumption) bool {
+	if strings.EqualFold(a.Category, "portion") {
+		return true
+	}
+	field := strings.ToLower(a.Field)
+	for _, word := range portionFields {
+		if strings.Contains(field, word) {
+			return true
+		}
+	}
+	return false
+}
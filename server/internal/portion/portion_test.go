@@ -0,0 +1,58 @@
+package portion
+
+import (
+	"testing"
+
+	"github.com/simhozebs/mugo/internal/models"
+)
+
+func TestScale(t *testing.T) {
+	tests := []struct {
+		name       string
+		assumption models.Assumption
+		factor     float64
+		wantValue  float64
+		wantUnit   string
+	}{
+		{"grams", models.Assumption{Field: "chicken_weight", AssumedValue: 150, Unit: "g"}, 1.5, 225, "g"},
+		{"small grams keep a decimal", models.Assumption{Field: "butter_weight", AssumedValue: 5, Unit: "g"}, 0.25, 1.3, "g"},
+		{"milliliters", models.Assumption{Field: "milk_volume", AssumedValue: 250, Unit: "ml"}, 0.5, 125, "ml"},
+		{"teaspoons", models.Assumption{Field: "sugar_amount", AssumedValue: 1, Unit: "tsp"}, 2, 2, "tsp"},
+		{"teaspoons up to tablespoons", models.Assumption{Field: "sugar_amount", AssumedValue: 2, Unit: "tsp"}, 3, 2, "tbsp"},
+		{"tablespoons", models.Assumption{Field: "oil_amount", AssumedValue: 1, Unit: "tbsp olive oil"}, 1.5, 1.5, "tbsp olive oil"},
+		{"tablespoons up to cups", models.Assumption{Field: "oil_amount", AssumedValue: 2, Unit: "tbsp"}, 4, 0.5, "cup"},
+		{"cups", models.Assumption{Field: "rice_volume", AssumedValue: 1, Unit: "cup cooked rice"}, 1.5, 1.5, "cup cooked rice"},
+		{"cups down to tablespoons", models.Assumption{Field: "rice_volume", AssumedValue: 0.25, Unit: "cup"}, 0.5, 2, "tbsp"},
+		{"portion in other units", models.Assumption{Category: "portion", Field: "bread", AssumedValue: 2, Unit: "slices"}, 1.5, 3, "slices"},
+		{"not a portion", models.Assumption{Category: "preparation", Field: "cooking_time", AssumedValue: 20, Unit: "minutes"}, 2, 20, "minutes"},
+		{"length", models.Assumption{Category: "preparation", Field: "pizza_diameter", AssumedValue: 30, Unit: "cm"}, 2, 30, "cm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := Scale(models.Macros{}, []models.Assumption{tt.assumption}, tt.factor)
+			if len(got) != 1 {
+				t.Fatalf("Scale returned %d assumptions, want 1", len(got))
+			}
+			if got[0].AssumedValue != tt.wantValue || got[0].Unit != tt.wantUnit {
+				t.Errorf("scaled = %v %q, want %v %q", got[0].AssumedValue, got[0].Unit, tt.wantValue, tt.wantUnit)
+			}
+			if got[0].Field != tt.assumption.Field || got[0].Category != tt.assumption.Category {
+				t.Errorf("scaled = %+v, want field and category kept", got[0])
+			}
+		})
+	}
+}
+
+func TestScaleMacros(t *testing.T) {
+	macros := models.Macros{Calories: 400, Protein: 30, Carbs: 40, Fat: 12}
+	assumptions := []models.Assumption{{Field: "chicken_weight", AssumedValue: 150, Unit: "g"}}
+
+	got, _ := Scale(macros, assumptions, 0.5)
+	want := models.Macros{Calories: 200, Protein: 15, Carbs: 20, Fat: 6}
+	if got != want {
+		t.Errorf("macros = %+v, want %+v", got, want)
+	}
+	if assumptions[0].AssumedValue != 150 {
+		t.Errorf("Scale changed the given assumptions: %+v", assumptions[0])
+	}
+}
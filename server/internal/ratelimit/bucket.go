@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// decision is the outcome of taking a token from a bucket.
+type decision struct {
+	allowed    bool
+	limit      int           // bucket size
+	remaining  int           // whole tokens left
+	retryAfter time.Duration // until the next token, when not allowed
+}
+
+// buckets holds a token bucket per key. Buckets that have refilled
+// completely are dropped, so idle keys cost no memory.
+type buckets struct {
+	mu        sync.Mutex
+	entries   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens    float64
+	updated   time.Time
+	perMinute int
+	burst     int
+}
+
+// refill adds the tokens accrued since the last update.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.updated).Minutes()*float64(b.perMinute))
+	b.updated = now
+}
+
+// take takes a token from the bucket of key, which holds up to burst tokens
+// and refills at perMinute tokens per minute.
+func (bs *buckets) take(key string, perMinute, burst int) decision {
+	now := time.Now()
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+
+	if bs.entries == nil {
+		bs.entries = make(map[string]*bucket)
+	}
+	if now.Sub(bs.lastSweep) > time.Minute {
+		bs.sweep(now)
+	}
+
+	b, ok := bs.entries[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		bs.entries[key] = b
+	}
+	// The limits of a key change when, e.g., the user's plan does.
+	b.perMinute, b.burst = perMinute, burst
+	b.refill(now)
+
+	d := decision{limit: burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		d.allowed = true
+	} else {
+		d.retryAfter = time.Duration((1 - b.tokens) / float64(perMinute) * float64(time.Minute))
+	}
+	d.remaining = int(b.tokens)
+	return d
+}
+
+// sweep drops the buckets that are full again.
+func (bs *buckets) sweep(now time.Time) {
+	for key, b := range bs.entries {
+		b.refill(now)
+		if b.tokens >= float64(b.burst) {
+			delete(bs.entries, key)
+		}
+	}
+	bs.lastSweep = now
+}
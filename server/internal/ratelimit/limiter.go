@@ -0,0 +1,192 @@
+// Package ratelimit limits how often users call the agents, which spend LLM
+// tokens on every call.
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/danielgtaylor/huma/v2/adapters/humachi"
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/logging"
+)
+
+// Response headers describing the limits of the caller.
+const (
+	HeaderLimit          = "X-RateLimit-Limit"
+	HeaderRemaining      = "X-RateLimit-Remaining"
+	HeaderQuotaLimit     = "X-Quota-Limit"
+	HeaderQuotaRemaining = "X-Quota-Remaining"
+	HeaderQuotaReset     = "X-Quota-Reset"
+)
+
+// MetadataIdempotencyOperation is the operation metadata key naming the
+// idempotency operation of an endpoint. Requests replaying a stored response
+// of that operation are not charged to the user.
+const MetadataIdempotencyOperation = "idempotency_operation"
+
+// maxPeekBytes bounds how much of a request body is read to find the user.
+// It matches huma's default body limit, so larger bodies are rejected anyway.
+const maxPeekBytes = 1 << 20
+
+// Limiter enforces the limits of the configured plans: a token bucket per
+// user and per client IP, kept in memory, and a daily quota per user, kept in
+// the database so it holds across restarts and API processes.
+type Limiter struct {
+	cfg      config.RateLimitConfig
+	keyTTL   time.Duration
+	database *db.Database
+	ips      buckets
+	users    buckets
+}
+
+// NewLimiter creates a limiter. keyTTL is how long idempotency keys live.
+// Without a database, users still have token buckets, with the default plan,
+// but no daily quota.
+func NewLimiter(cfg config.RateLimitConfig, keyTTL time.Duration, database *db.Database) *Limiter {
+	return &Limiter{cfg: cfg, keyTTL: keyTTL, database: database}
+}
+
+// Middleware returns huma middleware that limits the operations it wraps.
+// The user is the user_id path parameter or the user_id of the JSON request
+// body. Rejected requests get 429 with a Retry-After header.
+func (l *Limiter) Middleware(api huma.API) func(ctx huma.Context, next func(huma.Context)) {
+	return func(ctx huma.Context, next func(huma.Context)) {
+		logger := logging.FromContext(ctx.Context())
+		userID := peekUserID(ctx)
+		planName, plan := l.plan(ctx, userID)
+
+		ip := clientIP(ctx.RemoteAddr())
+		d := l.ips.take(ip, l.cfg.IPRequestsPerMinute, l.cfg.IPBurst)
+		// A replay of a stored response does not call the agent, so only the
+		// client IP pays for it.
+		replay := l.isReplay(ctx, userID)
+		if userID != "" && !replay {
+			userDecision := l.users.take(userID, plan.RequestsPerMinute, plan.Burst)
+			if !userDecision.allowed || (d.allowed && userDecision.remaining < d.remaining) {
+				d = userDecision
+			}
+		}
+		ctx.SetHeader(HeaderLimit, strconv.Itoa(d.limit))
+		ctx.SetHeader(HeaderRemaining, strconv.Itoa(d.remaining))
+		if !d.allowed {
+			logger.Info("Rate limit exceeded", "user_id", userID, "ip", ip, "plan", planName)
+			reject(api, ctx, d.retryAfter, "Rate limit exceeded")
+			return
+		}
+
+		if l.database == nil || userID == "" || replay || plan.DailyAgentCalls == 0 {
+			next(ctx)
+			return
+		}
+
+		now := time.Now().UTC()
+		day := now.Truncate(24 * time.Hour)
+		reset := day.Add(24 * time.Hour)
+		used, ok, err := l.database.UsageRepository.ConsumeInvocation(ctx.Context(), userID, day, plan.DailyAgentCalls)
+		if err != nil {
+			// An unreachable database should not take the agents down with it.
+			logger.Warn("Failed to check daily quota", "user_id", userID, "error", err)
+			next(ctx)
+			return
+		}
+		ctx.SetHeader(HeaderQuotaLimit, strconv.Itoa(plan.DailyAgentCalls))
+		ctx.SetHeader(HeaderQuotaRemaining, strconv.Itoa(plan.DailyAgentCalls-used))
+		ctx.SetHeader(HeaderQuotaReset, strconv.FormatInt(reset.Unix(), 10))
+		if !ok {
+			logger.Info("Daily quota exhausted", "user_id", userID, "plan", planName)
+			reject(api, ctx, reset.Sub(now),
+				fmt.Sprintf("Daily quota of %d agent calls exhausted", plan.DailyAgentCalls))
+			return
+		}
+
+		next(ctx)
+	}
+}
+
+// isReplay reports whether the request carries an Idempotency-Key the user
+// already used for the operation. withIdempotency answers such requests from
+// the stored response, or rejects them, without running the handler.
+func (l *Limiter) isReplay(ctx huma.Context, userID string) bool {
+	operation, _ := ctx.Operation().Metadata[MetadataIdempotencyOperation].(string)
+	key := ctx.Header("Idempotency-Key")
+	if l.database == nil || userID == "" || operation == "" || key == "" {
+		return false
+	}
+	existing, err := l.database.IdempotencyRepository.Get(ctx.Context(), operation, userID, key)
+	if err != nil {
+		return false
+	}
+	return existing.CreatedAt.After(time.Now().Add(-l.keyTTL))
+}
+
+// plan returns the plan of the user, falling back to the default plan.
+func (l *Limiter) plan(ctx huma.Context, userID string) (string, config.PlanConfig) {
+	name := l.cfg.DefaultPlan
+	if l.database != nil && userID != "" {
+		// IDs that are not UUIDs belong to no registered user.
+		if userPlan, err := l.database.UserRepository.GetPlan(ctx.Context(), userID); err == nil && userPlan != "" {
+			name = userPlan
+		}
+	}
+	plan, ok := l.cfg.Plans[name]
+	if !ok {
+		// A plan removed from the configuration but still set on users.
+		name = l.cfg.DefaultPlan
+		plan = l.cfg.Plans[name]
+	}
+	return name, plan
+}
+
+// reject answers 429 with a Retry-After header in whole seconds.
+func reject(api huma.API, ctx huma.Context, retryAfter time.Duration, msg string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	ctx.SetHeader("Retry-After", strconv.Itoa(max(seconds, 1)))
+	_ = huma.WriteErr(api, ctx, http.StatusTooManyRequests, msg)
+}
+
+// peekUserID returns the user_id path parameter or else the user_id of the
+// JSON request body, leaving the body for the handler to read.
+func peekUserID(ctx huma.Context) string {
+	if userID := ctx.Param("user_id"); userID != "" {
+		return userID
+	}
+	r, _ := humachi.Unwrap(ctx)
+	if r.Body == nil {
+		return ""
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBytes))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var body struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.Unmarshal(data, &body); err != nil {
+		return ""
+	}
+	return body.UserID
+}
+
+// clientIP returns the IP of a host:port remote address.
+func clientIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+)
+
+type UpdateUserPlanRequest struct {
+	UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Body   struct {
+		Plan string `json:"plan" example:"pro" doc:"Rate limit plan; empty for the default plan"`
+	}
+}
+
+// RegisterAdminEndpoints registers the admin endpoints. Every request must
+// carry the configured admin token as a bearer token.
+func RegisterAdminEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, database *db.Database) {
+	adminGroup := huma.NewGroup(humaAPI, prefix)
+	adminGroup.UseMiddleware(func(ctx huma.Context, next func(huma.Context)) {
+		token, ok := strings.CutPrefix(ctx.Header("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Admin.Token.Value())) != 1 {
+			ctx.SetHeader("WWW-Authenticate", "Bearer")
+			_ = huma.WriteErr(humaAPI, ctx, http.StatusUnauthorized, "A valid admin token is required")
+			return
+		}
+		next(ctx)
+	})
+
+	huma.Put(adminGroup, "/users/{user_id}/plan", func(ctx context.Context, input *UpdateUserPlanRequest) (*GetUserResponse, error) {
+		if _, ok := cfg.RateLimit.Plans[input.Body.Plan]; input.Body.Plan != "" && !ok {
+			plans := slices.Sorted(maps.Keys(cfg.RateLimit.Plans))
+			return nil, huma.Error422UnprocessableEntity(
+				fmt.Sprintf("Unknown plan '%s', expected one of %s", input.Body.Plan, strings.Join(plans, ", ")))
+		}
+
+		user, err := database.UserRepository.UpdatePlan(ctx, input.UserID, input.Body.Plan)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update plan: %w", err)
+		}
+		if user == nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("User '%s' not found", input.UserID))
+		}
+
+		resp := &GetUserResponse{}
+		renderUsers(user)
+		resp.Body.User = user
+		return resp, nil
+	})
+}
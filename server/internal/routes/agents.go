@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
@@ -11,26 +12,34 @@ import (
 	"github.com/simhozebs/mugo/internal/api"
 	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/jobs"
+	"github.com/simhozebs/mugo/internal/logging"
 	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/ratelimit"
+	"github.com/simhozebs/mugo/internal/telemetry"
 	adkmodels "google.golang.org/adk/server/restapi/models"
 	"google.golang.org/genai"
 )
 
-// RegisterAgentEndpoints registers all agent-related endpoints.
-func RegisterAgentEndpoints(humaAPI huma.API, prefix string, adkClient *adk.Client, database *db.Database) {
+// RegisterAgentEndpoints registers all agent-related endpoints. A nil
+// limiter leaves them unlimited.
+func RegisterAgentEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database, limiter *ratelimit.Limiter) {
 	agentsGroup := huma.NewGroup(humaAPI, prefix)
+	if limiter != nil {
+		agentsGroup.UseMiddleware(limiter.Middleware(humaAPI))
+	}
 
 	// Weather endpoint
 	huma.Post(agentsGroup, "/weather", func(ctx context.Context, input *api.WeatherRequest) (*api.WeatherResponse, error) {
-		appName, ok := config.AgentMapping["weather"]
+		appName, ok := cfg.Agents.Mapping["weather"]
 		if !ok {
 			return nil, fmt.Errorf("weather agent not configured")
 		}
 
-		fmt.Printf("Received weather request for city: %s (user: %s, session: %s)\n",
-			input.Body.City, input.Body.UserID, input.Body.SessionID)
+		logging.FromContext(ctx).Info("Received weather request",
+			"city", logging.UserText(input.Body.City), "user_id", input.Body.UserID, "session_id", input.Body.SessionID)
 
-		result, err := adkClient.RunWithAutoSession(ctx, adkmodels.RunAgentRequest{
+		result, err := agentRunner.RunWithAutoSession(ctx, adkmodels.RunAgentRequest{
 			AppName:   appName,
 			UserId:    input.Body.UserID,
 			SessionId: input.Body.SessionID,
@@ -38,7 +47,7 @@ func RegisterAgentEndpoints(humaAPI huma.API, prefix string, adkClient *adk.Clie
 				Role:  string(genai.RoleUser),
 				Parts: []*genai.Part{{Text: input.Body.City}},
 			},
-		})
+		}, nil)
 		if err != nil {
 			return nil, fmt.Errorf("weather agent processing failed: %w", err)
 		}
@@ -50,50 +59,152 @@ func RegisterAgentEndpoints(humaAPI huma.API, prefix string, adkClient *adk.Clie
 
 	// Nutrition endpoint
 	huma.Post(agentsGroup, "/nutrition", func(ctx context.Context, input *api.NutritionRequest) (*api.NutritionResponse, error) {
-		appName, ok := config.AgentMapping["nutrition"]
+		appName, ok := cfg.Agents.Mapping["nutrition"]
 		if !ok {
 			return nil, fmt.Errorf("nutrition agent not configured")
 		}
 
-		fmt.Printf("Received nutrition request: %s (user: %s, session: %s)\n",
-			input.Body.Text, input.Body.UserID, input.Body.SessionID)
+		logging.FromContext(ctx).Info("Received nutrition request",
+			"text", logging.UserText(input.Body.Text), "user_id", input.Body.UserID, "session_id", input.Body.SessionID)
 
-		result, err := adkClient.RunWithAutoSession(ctx, adkmodels.RunAgentRequest{
-			AppName:   appName,
-			UserId:    input.Body.UserID,
-			SessionId: input.Body.SessionID,
-			NewMessage: genai.Content{
-				Role:  string(genai.RoleUser),
-				Parts: []*genai.Part{{Text: input.Body.Text}},
-			},
+		// Retried requests with the same Idempotency-Key must not log the meal twice.
+		return withIdempotency(ctx, database, cfg.Idempotency.KeyTTL, "nutrition", input.Body.UserID, input.IdempotencyKey, input.Body, func() (*api.NutritionResponse, error) {
+			body, err := estimateNutrition(ctx, agentRunner, database, appName, input.Body)
+			if err != nil {
+				return nil, err
+			}
+			return &api.NutritionResponse{Body: *body}, nil
 		})
-		if err != nil {
-			return nil, fmt.Errorf("nutrition agent processing failed: %w", err)
+	}, func(o *huma.Operation) {
+		o.Metadata[ratelimit.MetadataIdempotencyOperation] = "nutrition"
+	})
+
+	// Estimates can outlast client timeouts, so they can also run as jobs.
+	if database != nil {
+		huma.Register(agentsGroup, huma.Operation{
+			OperationID:   "post-agents-nutrition-jobs",
+			Method:        http.MethodPost,
+			Path:          "/nutrition/jobs",
+			Summary:       "Enqueue a nutrition estimate",
+			DefaultStatus: http.StatusAccepted,
+			Metadata:      map[string]any{ratelimit.MetadataIdempotencyOperation: "nutrition_job"},
+		}, func(ctx context.Context, input *api.NutritionJobRequest) (*api.JobResponse, error) {
+			if input.Body.CallbackURL != "" {
+				if err := jobs.CheckCallbackURL(input.Body.CallbackURL, cfg.Jobs.CallbackHosts); err != nil {
+					return nil, huma.Error422UnprocessableEntity(err.Error())
+				}
+			}
+
+			// Retried requests with the same Idempotency-Key must not enqueue the estimate twice.
+			return withIdempotency(ctx, database, cfg.Idempotency.KeyTTL, "nutrition_job", input.Body.UserID, input.IdempotencyKey, input.Body, func() (*api.JobResponse, error) {
+				job, err := database.JobRepository.Enqueue(ctx,
+					models.JobKindNutritionEstimate,
+					input.Body.UserID,
+					input.Body.NutritionRequestBody,
+					cfg.Jobs.MaxAttempts,
+					input.Body.CallbackURL,
+				)
+				if err != nil {
+					return nil, err
+				}
+
+				resp := &api.JobResponse{}
+				resp.Body.Job = job
+				return resp, nil
+			})
+		})
+	}
+}
+
+// NutritionJobHandler returns the job handler that runs queued nutrition estimates.
+func NutritionJobHandler(cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) (any, error) {
+		appName, ok := cfg.Agents.Mapping["nutrition"]
+		if !ok {
+			return nil, fmt.Errorf("nutrition agent not configured")
 		}
 
-		resp := &api.NutritionResponse{}
-		var payload models.NutritionPayload
-		if err := json.Unmarshal([]byte(result.FinalText), &payload); err != nil {
-			return nil, fmt.Errorf("failed to parse nutrition response: %w", err)
+		var body api.NutritionRequestBody
+		if err := json.Unmarshal(job.Payload, &body); err != nil {
+			return nil, fmt.Errorf("failed to decode job payload: %w", err)
 		}
-		resp.Body.Analysis = payload
-		resp.Body.SessionID = input.Body.SessionID
-
-		// Persist to database if available
-		if database != nil {
-			_, _ = database.MealLogRepository.Create(ctx,
-				input.Body.UserID,
-				input.Body.SessionID,
-				payload.Name,
-				string(payload.MealType),
-				time.Now(),
-				payload.Macros,
-				payload.Assumptions,
-				"ai_estimated",
-				payload,
-			)
+		return estimateNutrition(ctx, agentRunner, database, appName, body)
+	}
+}
+
+// estimateNutrition runs the nutrition agent on a meal description and logs
+// the estimated meal when a database is available.
+func estimateNutrition(ctx context.Context, agentRunner adk.AgentRunner, database *db.Database, appName string, body api.NutritionRequestBody) (*api.NutritionResponseBody, error) {
+	// Dietary context sent with the request wins over the stored profile.
+	profile := models.DietaryProfile{
+		Preferences: body.DietaryPreferences,
+		Allergens:   body.Allergens,
+	}
+	if profile.IsEmpty() && database != nil {
+		if user, err := database.UserRepository.GetByID(ctx, body.UserID); err == nil {
+			profile = models.DietaryProfileFromMetadata(user.Metadata)
 		}
+	}
 
-		return resp, nil
-	})
+	// The profile is applied on every run, so changes to it reach sessions
+	// that already exist.
+	result, err := agentRunner.RunWithAutoSession(ctx, adkmodels.RunAgentRequest{
+		AppName:   appName,
+		UserId:    body.UserID,
+		SessionId: body.SessionID,
+		NewMessage: genai.Content{
+			Role:  string(genai.RoleUser),
+			Parts: []*genai.Part{{Text: body.Text}},
+		},
+	}, profile.SessionState())
+	if err != nil {
+		telemetry.RecordEstimateFailure(ctx, "agent")
+		return nil, fmt.Errorf("nutrition agent processing failed: %w", err)
+	}
+
+	var payload models.NutritionPayload
+	if err := json.Unmarshal([]byte(result.FinalText), &payload); err != nil {
+		telemetry.RecordEstimateFailure(ctx, "parse")
+		return nil, fmt.Errorf("failed to parse nutrition response: %w", err)
+	}
+
+	// Persist to database if available
+	if database != nil {
+		// The session service links sessions of known users to a conversation.
+		conversationID := ""
+		if conversation, err := database.ConversationRepository.GetBySessionID(ctx, body.UserID, body.SessionID); err == nil {
+			conversationID = conversation.ID
+		}
+		meal, err := database.MealLogRepository.Create(ctx,
+			body.UserID,
+			conversationID,
+			payload.Name,
+			string(payload.MealType),
+			time.Now(),
+			payload.Macros,
+			payload.Assumptions,
+			"ai_estimated",
+			payload,
+		)
+		// Name new conversations after the first meal estimated in them.
+		if err == nil && conversationID != "" && payload.Name != "" {
+			if _, err := database.ConversationRepository.SetTitleIfUntitled(ctx, conversationID, conversationTitle(payload.Name)); err != nil {
+				logging.FromContext(ctx).Warn("Failed to title conversation", "conversation_id", conversationID, "error", err)
+			}
+		}
+		// Attribute the tokens spent on the estimate to the meal.
+		if invocationID := result.InvocationID(); err == nil && invocationID != "" {
+			if err := database.UsageRepository.LinkMeal(ctx, invocationID, meal.ID); err != nil {
+				logging.FromContext(ctx).Warn("Failed to link LLM usage to meal", "meal_id", meal.ID, "error", err)
+			}
+		}
+		// The meal keeps the estimate as the agent gave it; the response
+		// shows it in the user's units.
+		payload.Assumptions = renderAssumptions(unitSystem(ctx, database, body.UserID), payload.Assumptions)
+	}
+
+	return &api.NutritionResponseBody{
+		Analysis:  payload,
+		SessionID: body.SessionID,
+	}, nil
 }
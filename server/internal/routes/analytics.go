@@ -6,8 +6,15 @@ import (
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/db/repository"
 	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/ratelimit"
+	adkmodels "google.golang.org/adk/server/restapi/models"
+	"google.golang.org/genai"
 )
 
 type GetDailySummaryResponse struct {
@@ -34,8 +41,28 @@ type ListWeeklySummariesResponse struct {
 	}
 }
 
-// RegisterAnalyticsEndpoints registers nutrition analytics endpoints.
-func RegisterAnalyticsEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+type GenerateReportRequest struct {
+	UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Body   struct {
+		WeekStartDate string `json:"week_start_date,omitempty" example:"2025-01-06" doc:"Week start date (YYYY-MM-DD), defaults to current week"`
+	}
+}
+
+type GetReportResponse struct {
+	Body struct {
+		Report *models.Report `json:"report"`
+	}
+}
+
+type ListReportsResponse struct {
+	Body struct {
+		Reports []*models.Report `json:"reports"`
+	}
+}
+
+// RegisterAnalyticsEndpoints registers nutrition analytics endpoints. The
+// limiter, if any, limits generating reports, which calls the coach agent.
+func RegisterAnalyticsEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database, limiter *ratelimit.Limiter) {
 	analyticsGroup := huma.NewGroup(humaAPI, prefix)
 
 	huma.Get(analyticsGroup, "/daily/{user_id}", func(ctx context.Context, input *struct {
@@ -80,8 +107,7 @@ func RegisterAnalyticsEndpoints(humaAPI huma.API, prefix string, database *db.Da
 	}) (*GetWeeklySummaryResponse, error) {
 		weekStart := parseDate(input.WeekStartDate)
 		if input.WeekStartDate == "" {
-			now := time.Now()
-			weekStart = now.AddDate(0, 0, -int(now.Weekday()-1))
+			weekStart = repository.WeekStart(time.Now().UTC().Truncate(24 * time.Hour))
 		}
 
 		summary, err := database.NutritionRepository.GetWeekly(ctx, input.UserID, weekStart)
@@ -110,4 +136,63 @@ func RegisterAnalyticsEndpoints(humaAPI huma.API, prefix string, database *db.Da
 		resp.Body.Summaries = summaries
 		return resp, nil
 	})
+
+	huma.Post(analyticsGroup, "/reports/{user_id}", func(ctx context.Context, input *GenerateReportRequest) (*GetReportResponse, error) {
+		appName, ok := cfg.Agents.Mapping["coach"]
+		if !ok {
+			return nil, fmt.Errorf("coach agent not configured")
+		}
+
+		weekStart := parseDate(input.Body.WeekStartDate)
+		if input.Body.WeekStartDate == "" {
+			weekStart = repository.WeekStart(time.Now().UTC().Truncate(24 * time.Hour))
+		}
+		weekEnd := weekStart.AddDate(0, 0, 6)
+
+		// Each report gets its own session so earlier reports don't leak into the narrative.
+		result, err := agentRunner.RunWithAutoSession(ctx, adkmodels.RunAgentRequest{
+			AppName:   appName,
+			UserId:    input.UserID,
+			SessionId: "report-" + uuid.NewString(),
+			NewMessage: genai.Content{
+				Role: string(genai.RoleUser),
+				Parts: []*genai.Part{{Text: fmt.Sprintf("Write my weekly nutrition report for the week from %s to %s.",
+					weekStart.Format("2006-01-02"), weekEnd.Format("2006-01-02"))}},
+			},
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("coach agent processing failed: %w", err)
+		}
+		if result.FinalText == "" {
+			return nil, huma.Error502BadGateway("coach agent returned an empty report")
+		}
+
+		report, err := database.ReportRepository.Create(ctx, input.UserID, models.ReportTypeWeeklyCoaching, weekStart, weekEnd, result.FinalText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save report: %w", err)
+		}
+
+		resp := &GetReportResponse{}
+		resp.Body.Report = report
+		return resp, nil
+	}, func(o *huma.Operation) {
+		if limiter != nil {
+			o.Middlewares = append(o.Middlewares, limiter.Middleware(humaAPI))
+		}
+	})
+
+	huma.Get(analyticsGroup, "/reports/{user_id}", func(ctx context.Context, input *struct {
+		UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+		Limit  int    `query:"limit" default:"12" doc:"Maximum number of reports to return"`
+		Offset int    `query:"offset" default:"0" doc:"Number of reports to skip"`
+	}) (*ListReportsResponse, error) {
+		reports, err := database.ReportRepository.ListByUser(ctx, input.UserID, input.Limit, input.Offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list reports: %w", err)
+		}
+
+		resp := &ListReportsResponse{}
+		resp.Body.Reports = reports
+		return resp, nil
+	})
 }
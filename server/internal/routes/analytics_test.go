@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2/humatest"
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/ratelimit"
+	"google.golang.org/adk/server/restapi/models"
+)
+
+// unavailableRunner fails every run. Its other methods are not implemented.
+type unavailableRunner struct {
+	adk.AgentRunner
+	runs int
+}
+
+func (r *unavailableRunner) RunWithAutoSession(ctx context.Context, runReq models.RunAgentRequest, state map[string]any) (*adk.RunResult, error) {
+	r.runs++
+	return nil, errors.New("agent unavailable")
+}
+
+func TestGenerateReportIsRateLimited(t *testing.T) {
+	cfg := &config.Config{Agents: config.AgentsConfig{Mapping: map[string]string{"coach": "nutrition_coach"}}}
+	limiter := ratelimit.NewLimiter(config.RateLimitConfig{
+		IPRequestsPerMinute: 60,
+		IPBurst:             10,
+		DefaultPlan:         "free",
+		Plans:               map[string]config.PlanConfig{"free": {RequestsPerMinute: 1, Burst: 1}},
+	}, time.Hour, nil)
+	runner := &unavailableRunner{}
+	_, api := humatest.New(t)
+	RegisterAnalyticsEndpoints(api, "/analytics", cfg, runner, nil, limiter)
+
+	if resp := api.Post("/analytics/reports/u1", map[string]any{}); resp.Code != http.StatusInternalServerError {
+		t.Fatalf("first report = %d, want the agent failure", resp.Code)
+	}
+	resp := api.Post("/analytics/reports/u1", map[string]any{})
+	if resp.Code != http.StatusTooManyRequests {
+		t.Fatalf("second report = %d, want %d", resp.Code, http.StatusTooManyRequests)
+	}
+	if resp.Header().Get("Retry-After") == "" {
+		t.Error("rejected report has no Retry-After header")
+	}
+	if runner.runs != 1 {
+		t.Errorf("coach agent ran %d times, want 1", runner.runs)
+	}
+
+	// The bucket is the user's, not the client's.
+	if resp := api.Post("/analytics/reports/u2", map[string]any{}); resp.Code != http.StatusInternalServerError {
+		t.Errorf("report of another user = %d, want the agent failure", resp.Code)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/simhozebs/mugo/internal/adk"
 	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/logging"
 	"google.golang.org/adk/server/restapi/models"
 	"google.golang.org/genai"
 )
@@ -25,18 +26,18 @@ type ConversationResponse struct {
 }
 
 // ConversationHandler handles conversation requests using the echo agent.
-func ConversationHandler(ctx context.Context, adkClient *adk.Client, input *struct {
+func ConversationHandler(ctx context.Context, cfg *config.Config, agentRunner adk.AgentRunner, input *struct {
 	Body ConversationRequest `body:""`
 }) (*ConversationResponse, error) {
-	appName, ok := config.AgentMapping["echo"]
+	appName, ok := cfg.Agents.Mapping["echo"]
 	if !ok {
 		return nil, fmt.Errorf("echo agent not configured")
 	}
 
-	fmt.Printf("Conversation request: %s (user: %s, session: %s)\n",
-		input.Body.Message, input.Body.UserID, input.Body.SessionID)
+	logging.FromContext(ctx).Info("Received conversation request",
+		"message", logging.UserText(input.Body.Message), "user_id", input.Body.UserID, "session_id", input.Body.SessionID)
 
-	result, err := adkClient.RunWithAutoSession(ctx, models.RunAgentRequest{
+	result, err := agentRunner.RunWithAutoSession(ctx, models.RunAgentRequest{
 		AppName:   appName,
 		UserId:    input.Body.UserID,
 		SessionId: input.Body.SessionID,
@@ -44,7 +45,7 @@ func ConversationHandler(ctx context.Context, adkClient *adk.Client, input *stru
 			Role:  string(genai.RoleUser),
 			Parts: []*genai.Part{{Text: input.Body.Message}},
 		},
-	})
+	}, nil)
 	if err != nil {
 		return nil, fmt.Errorf("agent processing failed: %w", err)
 	}
@@ -2,9 +2,15 @@ package routes
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"slices"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db"
 	"github.com/simhozebs/mugo/internal/models"
 )
@@ -21,11 +27,23 @@ type GetConversationResponse struct {
 	}
 }
 
-// RegisterConversationEndpoints registers conversation endpoints.
-func RegisterConversationEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+type UpdateConversationRequest struct {
+	ConversationID string `path:"conversation_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"Conversation ID"`
+	Body           struct {
+		Title string `json:"title" minLength:"1" maxLength:"255" example:"Breakfast at the cafe" doc:"New title of the conversation"`
+	}
+}
+
+// maxTitleLength is the length of conversations.title.
+const maxTitleLength = 255
+
+// RegisterConversationEndpoints registers conversation endpoints. A user's
+// conversations are listed under /by-user/{user_id}, so the paths of single
+// conversations do not collide with them.
+func RegisterConversationEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database) {
 	conversationsGroup := huma.NewGroup(humaAPI, prefix)
 
-	huma.Get(conversationsGroup, "/{user_id}", func(ctx context.Context, input *struct {
+	huma.Get(conversationsGroup, "/by-user/{user_id}", func(ctx context.Context, input *struct {
 		UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
 	}) (*ListConversationsResponse, error) {
 		conversations, err := database.ConversationRepository.ListByUser(ctx, input.UserID)
@@ -38,13 +56,13 @@ func RegisterConversationEndpoints(humaAPI huma.API, prefix string, database *db
 		return resp, nil
 	})
 
-	huma.Get(conversationsGroup, "/{user_id}/session/{session_id}", func(ctx context.Context, input *struct {
+	huma.Get(conversationsGroup, "/by-user/{user_id}/sessions/{session_id}", func(ctx context.Context, input *struct {
 		UserID    string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
 		SessionID string `path:"session_id" example:"session_12345" doc:"Session ID"`
 	}) (*GetConversationResponse, error) {
 		conversation, err := database.ConversationRepository.GetBySessionID(ctx, input.UserID, input.SessionID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get conversation: %w", err)
+			return nil, huma.Error404NotFound(fmt.Sprintf("No conversation for session '%s'", input.SessionID))
 		}
 
 		resp := &GetConversationResponse{}
@@ -57,11 +75,79 @@ func RegisterConversationEndpoints(humaAPI huma.API, prefix string, database *db
 	}) (*GetConversationResponse, error) {
 		conversation, err := database.ConversationRepository.GetByID(ctx, input.ConversationID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get conversation: %w", err)
+			return nil, huma.Error404NotFound(fmt.Sprintf("Conversation '%s' not found", input.ConversationID))
+		}
+
+		resp := &GetConversationResponse{}
+		resp.Body.Conversation = conversation
+		return resp, nil
+	})
+
+	huma.Patch(conversationsGroup, "/{conversation_id}", func(ctx context.Context, input *UpdateConversationRequest) (*GetConversationResponse, error) {
+		if _, err := database.ConversationRepository.GetByID(ctx, input.ConversationID); err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("Conversation '%s' not found", input.ConversationID))
+		}
+
+		conversation, err := database.ConversationRepository.UpdateTitle(ctx, input.ConversationID, input.Body.Title)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rename conversation: %w", err)
 		}
 
 		resp := &GetConversationResponse{}
 		resp.Body.Conversation = conversation
 		return resp, nil
 	})
+
+	huma.Register(conversationsGroup, huma.Operation{
+		OperationID:   "delete-conversation",
+		Method:        http.MethodDelete,
+		Path:          "/{conversation_id}",
+		Summary:       "Delete a conversation",
+		Description:   "Deletes the conversation, its messages and its agent session. Meals logged in the conversation are kept.",
+		DefaultStatus: http.StatusNoContent,
+	}, func(ctx context.Context, input *struct {
+		ConversationID string `path:"conversation_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"Conversation ID"`
+	}) (*struct{}, error) {
+		conversation, err := database.ConversationRepository.GetByID(ctx, input.ConversationID)
+		if err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("Conversation '%s' not found", input.ConversationID))
+		}
+
+		// The session goes first: if that fails, the conversation is still
+		// there to retry the delete with.
+		if _, err := deleteSessions(ctx, cfg, agentRunner, conversation.UserID, conversation.SessionID); err != nil {
+			return nil, err
+		}
+		if err := database.ConversationRepository.Delete(ctx, conversation.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete conversation: %w", err)
+		}
+		return nil, nil
+	})
+}
+
+// deleteSessions deletes a session of the user from every agent app and
+// returns how many apps had it. Conversations do not record their app.
+func deleteSessions(ctx context.Context, cfg *config.Config, agentRunner adk.AgentRunner, userID, sessionID string) (int, error) {
+	deleted := 0
+	for _, app := range slices.Compact(slices.Sorted(maps.Values(cfg.Agents.Mapping))) {
+		err := agentRunner.DeleteSession(ctx, app, userID, sessionID)
+		if errors.Is(err, adk.ErrSessionNotFound) {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete session %s of app %s: %w", sessionID, app, err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// conversationTitle derives a conversation title from the name of the first
+// meal estimated in it.
+func conversationTitle(mealName string) string {
+	runes := []rune(mealName)
+	if len(runes) > maxTitleLength {
+		runes = runes[:maxTitleLength]
+	}
+	return string(runes)
 }
@@ -29,8 +29,8 @@ type debugListSessionsResponse struct {
 }
 
 // RegisterDebugEndpoints registers debug endpoints.
-// Note: These endpoints now proxy to the ADK server for session information.
-func RegisterDebugEndpoints(humaAPI huma.API, prefix string, adkClient *adk.Client, database *db.Database) {
+// Note: These endpoints read session information from the agent runner.
+func RegisterDebugEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database) {
 	debugGroup := huma.NewGroup(humaAPI, prefix)
 
 	huma.Register(
@@ -73,9 +73,9 @@ func RegisterDebugEndpoints(humaAPI huma.API, prefix string, adkClient *adk.Clie
 			},
 		},
 		func(ctx context.Context, input *DebugGetMessagesRequest) (response *debugGetMessagesResponse, err error) {
-			appName := config.AgentMapping["nutrition"]
+			appName := cfg.Agents.Mapping["nutrition"]
 
-			session, err := adkClient.GetSession(ctx, appName, input.UserId, input.SessionId)
+			session, err := agentRunner.GetSession(ctx, appName, input.UserId, input.SessionId)
 			if err != nil {
 				return nil, huma.Error400BadRequest(fmt.Sprintf("Error retrieving session: %v", err))
 			}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/export"
+	"github.com/simhozebs/mugo/internal/logging"
+)
+
+type ExportRequest struct {
+	UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Format string `query:"format" enum:"csv,json,fhir" default:"json" doc:"csv: meal logs with flattened macros and assumptions; json: archive of the profile, meal logs and revisions, conversations, agent sessions with events, nutrition summaries, reports and LLM usage; fhir: FHIR R4 Bundle of NutritionIntake resources in their R5 shape, as R4 has no resource for food eaten"`
+}
+
+// RegisterExportEndpoints registers the data export endpoint under the users
+// prefix.
+func RegisterExportEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+	usersGroup := huma.NewGroup(humaAPI, prefix)
+
+	huma.Register(
+		usersGroup,
+		huma.Operation{
+			OperationID: "export-user-data",
+			Method:      http.MethodGet,
+			Path:        "/{user_id}/export",
+			Summary:     "Export a user's data",
+			Description: "Streams the user's data as a download. Errors after the first bytes truncate the download.",
+			Responses: map[string]*huma.Response{
+				"200": {
+					Description: "The export",
+					Content: map[string]*huma.MediaType{
+						export.FormatCSV.ContentType():  {},
+						export.FormatJSON.ContentType(): {},
+						export.FormatFHIR.ContentType(): {},
+					},
+				},
+			},
+		},
+		func(ctx context.Context, input *ExportRequest) (*huma.StreamResponse, error) {
+			user, err := database.UserRepository.GetByID(ctx, input.UserID)
+			if err != nil || user == nil {
+				return nil, huma.Error404NotFound(fmt.Sprintf("User '%s' not found", input.UserID))
+			}
+			format := export.Format(input.Format)
+
+			return &huma.StreamResponse{
+				Body: func(hctx huma.Context) {
+					hctx.SetHeader("Content-Type", format.ContentType())
+					hctx.SetHeader("Content-Disposition",
+						fmt.Sprintf("attachment; filename=%q", format.FileName(user, time.Now().UTC())))
+					hctx.SetStatus(http.StatusOK)
+
+					if err := export.Write(hctx.Context(), hctx.BodyWriter(), database, user, format); err != nil {
+						logging.FromContext(hctx.Context()).Error("Export failed",
+							"user_id", user.ID, "format", format, "error", err)
+					}
+				},
+			}, nil
+		},
+	)
+}
@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/config"
+	"github.com/simhozebs/mugo/internal/db"
+)
+
+// healthCheckTimeout bounds each dependency check of /readyz.
+const healthCheckTimeout = 3 * time.Second
+
+// Check statuses reported by /readyz.
+const (
+	CheckStatusOK    = "ok"
+	CheckStatusError = "error"
+)
+
+type HealthResponse struct {
+	Body struct {
+		Status string `json:"status" example:"ok" doc:"Always ok while the process serves requests"`
+	}
+}
+
+type DependencyCheck struct {
+	Status string `json:"status" enum:"ok,error" doc:"Result of the check"`
+	Error  string `json:"error,omitempty" doc:"Why the check failed"`
+}
+
+type ReadinessResponse struct {
+	Status int
+	Body   struct {
+		Status string                     `json:"status" enum:"ok,error" doc:"ok if every check passed"`
+		Checks map[string]DependencyCheck `json:"checks" doc:"Checks by dependency: database, adk and agents"`
+	}
+}
+
+// RegisterHealthEndpoints registers the liveness and readiness endpoints.
+// /healthz only reports that the process is up; /readyz checks the database,
+// the agent runner and that every agent the routes use is available, and
+// answers 503 if any check fails.
+func RegisterHealthEndpoints(humaAPI huma.API, cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database) {
+	huma.Get(humaAPI, "/healthz", func(ctx context.Context, input *struct{}) (*HealthResponse, error) {
+		resp := &HealthResponse{}
+		resp.Body.Status = CheckStatusOK
+		return resp, nil
+	})
+
+	huma.Get(humaAPI, "/readyz", func(ctx context.Context, input *struct{}) (*ReadinessResponse, error) {
+		checks := map[string]DependencyCheck{
+			"database": runCheck(ctx, func(ctx context.Context) error {
+				if database == nil {
+					return fmt.Errorf("not connected")
+				}
+				return database.Ping(ctx)
+			}),
+		}
+
+		var apps []string
+		checks["adk"] = runCheck(ctx, func(ctx context.Context) error {
+			var err error
+			apps, err = agentRunner.ListApps(ctx)
+			return err
+		})
+		checks["agents"] = runCheck(ctx, func(ctx context.Context) error {
+			if checks["adk"].Status != CheckStatusOK {
+				return fmt.Errorf("agent apps could not be listed")
+			}
+			var missing []string
+			for _, appName := range cfg.Agents.Mapping {
+				if !slices.Contains(apps, appName) {
+					missing = append(missing, appName)
+				}
+			}
+			if len(missing) > 0 {
+				sort.Strings(missing)
+				return fmt.Errorf("missing agent apps: %v", missing)
+			}
+			return nil
+		})
+
+		resp := &ReadinessResponse{Status: http.StatusOK}
+		resp.Body.Status = CheckStatusOK
+		resp.Body.Checks = checks
+		for _, check := range checks {
+			if check.Status != CheckStatusOK {
+				resp.Status = http.StatusServiceUnavailable
+				resp.Body.Status = CheckStatusError
+			}
+		}
+		return resp, nil
+	})
+}
+
+func runCheck(ctx context.Context, check func(ctx context.Context) error) DependencyCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	if err := check(ctx); err != nil {
+		return DependencyCheck{Status: CheckStatusError, Error: err.Error()}
+	}
+	return DependencyCheck{Status: CheckStatusOK}
+}
+
+// UnavailableHandler answers every request with 503 and the reason, in the
+// problem format huma uses for errors. It stands in for routes that need a
+// dependency the server runs without, so clients learn why instead of
+// getting a 404.
+func UnavailableHandler(reason string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_ = json.NewEncoder(w).Encode(huma.ErrorModel{
+			Title:  http.StatusText(http.StatusServiceUnavailable),
+			Status: http.StatusServiceUnavailable,
+			Detail: reason,
+		})
+	})
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/logging"
+)
+
+// withIdempotency runs handler at most once per Idempotency-Key of a user and
+// an operation. Keys of different users are independent.
+//
+// The first request with a key stores its response, and later requests with
+// the same key and payload get that response back without running handler.
+// A different payload for the same key is rejected with 422, and a request
+// arriving while the first one is still running with 409. Failed requests
+// release the key so the client can retry. Keys expire after ttl. Without a
+// key or a database, handler just runs.
+func withIdempotency[O any](ctx context.Context, database *db.Database, ttl time.Duration, operation, userID, key string, payload any, handler func() (*O, error)) (*O, error) {
+	if key == "" || database == nil {
+		return handler()
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash request: %w", err)
+	}
+	sum := sha256.Sum256(payloadJSON)
+	requestHash := hex.EncodeToString(sum[:])
+
+	repo := database.IdempotencyRepository
+	claimed, err := repo.Claim(ctx, operation, userID, key, requestHash, time.Now().Add(-ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	if !claimed {
+		existing, err := repo.Get(ctx, operation, userID, key)
+		if err != nil {
+			return nil, err
+		}
+		if existing.RequestHash != requestHash {
+			return nil, huma.Error422UnprocessableEntity("Idempotency-Key was already used for a different request")
+		}
+		if existing.Response == nil {
+			return nil, huma.Error409Conflict("A request with this Idempotency-Key is still being processed")
+		}
+		var replay O
+		if err := json.Unmarshal(existing.Response, &replay); err != nil {
+			return nil, fmt.Errorf("failed to decode stored response: %w", err)
+		}
+		return &replay, nil
+	}
+
+	resp, err := handler()
+	if err != nil {
+		// The key must not outlive the request that claimed it, even if the
+		// client went away.
+		if releaseErr := repo.Release(context.WithoutCancel(ctx), operation, userID, key); releaseErr != nil {
+			logging.FromContext(ctx).Warn("Failed to release idempotency key", "operation", operation, "error", releaseErr)
+		}
+		return nil, err
+	}
+
+	respJSON, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode response: %w", err)
+	}
+	if err := repo.Complete(context.WithoutCancel(ctx), operation, userID, key, respJSON); err != nil {
+		logger := logging.FromContext(ctx)
+		logger.Warn("Failed to store idempotent response", "operation", operation, "error", err)
+		if releaseErr := repo.Release(context.WithoutCancel(ctx), operation, userID, key); releaseErr != nil {
+			logger.Warn("Failed to release idempotency key", "operation", operation, "error", releaseErr)
+		}
+	}
+	return resp, nil
+}
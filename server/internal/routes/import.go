@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/importer"
+)
+
+type ImportRequest struct {
+	UserID   string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Source   string `query:"source" required:"true" enum:"myfitnesspal,cronometer" doc:"Tracker the file was exported from: the MyFitnessPal Nutrition Summary or the Cronometer Food & Recipe Entries export"`
+	Timezone string `query:"timezone" default:"UTC" example:"America/New_York" doc:"IANA time zone the dates and times of the file are in"`
+	RawBody  []byte `contentType:"text/csv"`
+}
+
+type ImportResponse struct {
+	Body *importer.Result
+}
+
+// maxImportBytes bounds the size of an imported file. Years of history are
+// well above huma's default body limit.
+const maxImportBytes = 32 << 20
+
+// RegisterImportEndpoints registers the meal history import endpoint under
+// the users prefix.
+func RegisterImportEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+	usersGroup := huma.NewGroup(humaAPI, prefix)
+
+	huma.Register(
+		usersGroup,
+		huma.Operation{
+			OperationID:  "import-user-meals",
+			Method:       http.MethodPost,
+			Path:         "/{user_id}/import",
+			Summary:      "Import meal history from another tracker",
+			Description:  "Logs the rows of a CSV export as meals and rebuilds the affected summaries. Rows imported before are skipped, so a file can be imported again.",
+			MaxBodyBytes: maxImportBytes,
+		},
+		func(ctx context.Context, input *ImportRequest) (*ImportResponse, error) {
+			loc, err := time.LoadLocation(input.Timezone)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("Unknown time zone '%s'", input.Timezone))
+			}
+			if _, err := database.UserRepository.GetByID(ctx, input.UserID); err != nil {
+				return nil, huma.Error404NotFound(fmt.Sprintf("User '%s' not found", input.UserID))
+			}
+
+			source := importer.Source(input.Source)
+			rows, rowErrors, err := importer.Parse(source, bytes.NewReader(input.RawBody), loc)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity(err.Error())
+			}
+			result, err := importer.Import(ctx, database, input.UserID, source, rows, rowErrors)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import meals: %w", err)
+			}
+			return &ImportResponse{Body: result}, nil
+		},
+	)
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/api"
+	"github.com/simhozebs/mugo/internal/db"
+)
+
+type GetJobRequest struct {
+	JobID string `path:"job_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"Job ID"`
+}
+
+// RegisterJobEndpoints registers endpoints for polling asynchronous jobs.
+func RegisterJobEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+	jobsGroup := huma.NewGroup(humaAPI, prefix)
+
+	huma.Get(jobsGroup, "/{job_id}", func(ctx context.Context, input *GetJobRequest) (*api.JobResponse, error) {
+		job, err := database.JobRepository.GetByID(ctx, input.JobID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get job: %w", err)
+		}
+		if job == nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("Job '%s' not found", input.JobID))
+		}
+
+		resp := &api.JobResponse{}
+		resp.Body.Job = job
+		return resp, nil
+	})
+}
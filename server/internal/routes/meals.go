@@ -2,12 +2,20 @@ package routes
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/google/uuid"
+	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db"
 	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/portion"
 )
 
 type ListMealsResponse struct {
@@ -22,6 +30,74 @@ type GetMealResponse struct {
 	}
 }
 
+type CreateMealRequest struct {
+	UserID         string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	IdempotencyKey string `header:"Idempotency-Key" example:"7c4a8d09-ca37-4c1e-a6a4-6f1b2f1e5d3a" doc:"Optional key that makes retries of this request return the first result instead of logging the meal again"`
+	Body           struct {
+		FoodName   string        `json:"food_name" minLength:"1" maxLength:"255" example:"Oatmeal with banana" doc:"Name of the food"`
+		MealType   string        `json:"meal_type" enum:"breakfast,lunch,dinner,snack,unknown" default:"unknown" doc:"Meal type"`
+		RecordedAt *time.Time    `json:"recorded_at,omitempty" doc:"When the meal was eaten; defaults to now"`
+		Macros     models.Macros `json:"macros" doc:"Macros of the meal"`
+	}
+}
+
+type DuplicateMealRequest struct {
+	MealID         string `path:"meal_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"ID of the meal to log again"`
+	IdempotencyKey string `header:"Idempotency-Key" example:"7c4a8d09-ca37-4c1e-a6a4-6f1b2f1e5d3a" doc:"Optional key that makes retries of this request return the first result instead of logging the meal again"`
+	Body           struct {
+		RecordedAt *time.Time `json:"recorded_at,omitempty" doc:"When the meal was eaten; defaults to now"`
+		MealType   string     `json:"meal_type,omitempty" enum:"breakfast,lunch,dinner,snack,unknown" doc:"Meal type; defaults to the original's"`
+		Portion    float64    `json:"portion,omitempty" exclusiveMinimum:"0" maximum:"20" default:"1" doc:"Portion relative to the original meal; the macros and portion assumptions are scaled by it"`
+	}
+}
+
+type ScaleMealRequest struct {
+	MealID         string `path:"meal_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"ID of the meal to scale"`
+	IdempotencyKey string `header:"Idempotency-Key" example:"7c4a8d09-ca37-4c1e-a6a4-6f1b2f1e5d3a" doc:"Optional key that makes retries of this request return the first result instead of scaling the meal again"`
+	Body           struct {
+		Factor float64 `json:"factor" exclusiveMinimum:"0" maximum:"20" example:"0.5" doc:"How much of the estimated portion was eaten; 0.5 halves it"`
+	}
+}
+
+type ScaleMealResponse struct {
+	Body struct {
+		Meal     *models.MealLog      `json:"meal"`
+		Revision *models.MealRevision `json:"revision"`
+	}
+}
+
+type ListMealRevisionsResponse struct {
+	Body struct {
+		Revisions []*models.MealRevision `json:"revisions" doc:"Revisions of the meal, oldest first"`
+	}
+}
+
+type FrequentMealsResponse struct {
+	Body struct {
+		Meals []*models.FrequentMeal `json:"meals" doc:"Foods, most logged first"`
+	}
+}
+
+type SearchMealsRequest struct {
+	UserID      string                 `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Query       string                 `query:"q" required:"true" minLength:"1" maxLength:"200" example:"ramen" doc:"Words to look for in food names and assumptions; misspellings still match"`
+	MealType    string                 `query:"meal_type" enum:"breakfast,lunch,dinner,snack,unknown" doc:"Only meals of this type"`
+	FoodSource  string                 `query:"food_source" enum:"ai_estimated,db_lookup,manual_entry" doc:"Only meals from this source"`
+	StartDate   string                 `query:"start_date" format:"date" example:"2025-01-01" doc:"Only meals recorded on or after this UTC day (YYYY-MM-DD)"`
+	EndDate     string                 `query:"end_date" format:"date" example:"2025-01-31" doc:"Only meals recorded on or before this UTC day (YYYY-MM-DD)"`
+	MinCalories OptionalParam[float64] `query:"min_calories" doc:"Only meals with at least this many calories"`
+	MaxCalories OptionalParam[float64] `query:"max_calories" doc:"Only meals with at most this many calories"`
+	Limit       int                    `query:"limit" default:"20" minimum:"1" maximum:"100" doc:"Maximum number of meals to return"`
+	Cursor      string                 `query:"cursor" doc:"next_cursor of the previous page"`
+}
+
+type SearchMealsResponse struct {
+	Body struct {
+		Results    []*models.MealSearchResult `json:"results" doc:"Matching meals, best match first"`
+		NextCursor string                     `json:"next_cursor,omitempty" doc:"Cursor of the next page; absent on the last page"`
+	}
+}
+
 type ListMealsByDateRangeRequest struct {
 	UserID    string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
 	StartDate string `query:"start_date" example:"2025-01-01" doc:"Start date (YYYY-MM-DD)"`
@@ -29,9 +105,44 @@ type ListMealsByDateRangeRequest struct {
 }
 
 // RegisterMealEndpoints registers meal log endpoints.
-func RegisterMealEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+func RegisterMealEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, database *db.Database) {
 	mealsGroup := huma.NewGroup(humaAPI, prefix)
 
+	huma.Post(mealsGroup, "/{user_id}", func(ctx context.Context, input *CreateMealRequest) (*GetMealResponse, error) {
+		payload := struct {
+			UserID string `json:"user_id"`
+			Body   any    `json:"body"`
+		}{input.UserID, input.Body}
+
+		// Retried requests with the same Idempotency-Key must not log the meal twice.
+		return withIdempotency(ctx, database, cfg.Idempotency.KeyTTL, "create_meal", input.UserID, input.IdempotencyKey, payload, func() (*GetMealResponse, error) {
+			recordedAt := time.Now()
+			if input.Body.RecordedAt != nil {
+				recordedAt = *input.Body.RecordedAt
+			}
+
+			meal, err := database.MealLogRepository.Create(ctx,
+				input.UserID,
+				"",
+				input.Body.FoodName,
+				input.Body.MealType,
+				recordedAt,
+				input.Body.Macros,
+				[]models.Assumption{},
+				"manual_entry",
+				nil,
+			)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create meal: %w", err)
+			}
+			renderMeals(unitSystem(ctx, database, input.UserID), meal)
+
+			resp := &GetMealResponse{}
+			resp.Body.Meal = meal
+			return resp, nil
+		})
+	})
+
 	huma.Get(mealsGroup, "/{user_id}", func(ctx context.Context, input *struct {
 		UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
 		Limit  int    `query:"limit" default:"50" doc:"Maximum number of meals to return"`
@@ -42,6 +153,8 @@ func RegisterMealEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 			return nil, fmt.Errorf("failed to list meals: %w", err)
 		}
 
+		renderMeals(unitSystem(ctx, database, input.UserID), meals...)
+
 		resp := &ListMealsResponse{}
 		resp.Body.Meals = meals
 		return resp, nil
@@ -56,6 +169,8 @@ func RegisterMealEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 			return nil, fmt.Errorf("failed to list meals by date: %w", err)
 		}
 
+		renderMeals(unitSystem(ctx, database, input.UserID), meals...)
+
 		resp := &ListMealsResponse{}
 		resp.Body.Meals = meals
 		return resp, nil
@@ -71,6 +186,8 @@ func RegisterMealEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 			return nil, fmt.Errorf("failed to list meals by date range: %w", err)
 		}
 
+		renderMeals(unitSystem(ctx, database, input.UserID), meals...)
+
 		resp := &ListMealsResponse{}
 		resp.Body.Meals = meals
 		return resp, nil
@@ -85,11 +202,194 @@ func RegisterMealEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 			return nil, fmt.Errorf("failed to list meals by conversation: %w", err)
 		}
 
+		renderMeals(unitSystem(ctx, database, input.UserID), meals...)
+
 		resp := &ListMealsResponse{}
 		resp.Body.Meals = meals
 		return resp, nil
 	})
 
+	huma.Get(mealsGroup, "/{user_id}/search", func(ctx context.Context, input *SearchMealsRequest) (*SearchMealsResponse, error) {
+		filter := models.MealSearchFilter{
+			Query:       input.Query,
+			MealType:    input.MealType,
+			FoodSource:  input.FoodSource,
+			MinCalories: input.MinCalories.Ptr(),
+			MaxCalories: input.MaxCalories.Ptr(),
+		}
+		if input.StartDate != "" {
+			filter.RecordedFrom = parseDate(input.StartDate)
+		}
+		if input.EndDate != "" {
+			filter.RecordedBefore = parseDate(input.EndDate).AddDate(0, 0, 1)
+		}
+
+		var afterRank *float64
+		var afterID string
+		if input.Cursor != "" {
+			rank, id, err := decodeSearchCursor(input.Cursor)
+			if err != nil {
+				return nil, huma.Error422UnprocessableEntity("Invalid cursor")
+			}
+			afterRank, afterID = &rank, id
+		}
+
+		results, err := database.MealLogRepository.Search(ctx, input.UserID, filter, afterRank, afterID, input.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to search meals: %w", err)
+		}
+		system := unitSystem(ctx, database, input.UserID)
+		for _, result := range results {
+			renderMeals(system, result.Meal)
+		}
+
+		resp := &SearchMealsResponse{}
+		resp.Body.Results = results
+		if len(results) == input.Limit {
+			last := results[len(results)-1]
+			resp.Body.NextCursor = encodeSearchCursor(last.Rank, last.Meal.ID)
+		}
+		return resp, nil
+	})
+
+	huma.Post(mealsGroup, "/meal/{meal_id}/duplicate", func(ctx context.Context, input *DuplicateMealRequest) (*GetMealResponse, error) {
+		original, err := database.MealLogRepository.GetByID(ctx, input.MealID)
+		if err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("Meal '%s' not found", input.MealID))
+		}
+
+		payload := struct {
+			MealID string `json:"meal_id"`
+			Body   any    `json:"body"`
+		}{input.MealID, input.Body}
+
+		// Retried requests with the same Idempotency-Key must not log the meal twice.
+		return withIdempotency(ctx, database, cfg.Idempotency.KeyTTL, "duplicate_meal", original.UserID, input.IdempotencyKey, payload, func() (*GetMealResponse, error) {
+			recordedAt := time.Now()
+			if input.Body.RecordedAt != nil {
+				recordedAt = *input.Body.RecordedAt
+			}
+			mealType := original.MealType
+			if input.Body.MealType != "" {
+				mealType = input.Body.MealType
+			}
+			factor := input.Body.Portion
+			if factor == 0 {
+				factor = 1
+			}
+			// Portion assumptions are scaled with the macros, as when a meal is scaled.
+			macros, assumptions := original.Macros, slices.Clone(original.Assumptions)
+			if factor != 1 {
+				macros, assumptions = portion.Scale(original.Macros, original.Assumptions, factor)
+			}
+
+			var meal *models.MealLog
+			err := database.WithTx(ctx, func(ctx context.Context, txDB *db.TxDatabase) error {
+				var err error
+				meal, err = txDB.MealLogRepository.Create(ctx,
+					original.UserID,
+					"",
+					original.FoodName,
+					mealType,
+					recordedAt,
+					macros,
+					assumptions,
+					original.FoodSource,
+					nil,
+				)
+				if err != nil {
+					return fmt.Errorf("failed to duplicate meal: %w", err)
+				}
+				return txDB.NutritionRepository.Rebuild(ctx, meal.UserID, []time.Time{recordedAt})
+			})
+			if err != nil {
+				return nil, err
+			}
+			renderMeals(unitSystem(ctx, database, meal.UserID), meal)
+
+			resp := &GetMealResponse{}
+			resp.Body.Meal = meal
+			return resp, nil
+		})
+	})
+
+	huma.Post(mealsGroup, "/meal/{meal_id}/scale", func(ctx context.Context, input *ScaleMealRequest) (*ScaleMealResponse, error) {
+		// The key belongs to the meal's owner, so look the owner up first.
+		owner, err := database.MealLogRepository.GetByID(ctx, input.MealID)
+		if err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("Meal '%s' not found", input.MealID))
+		}
+
+		payload := struct {
+			MealID string `json:"meal_id"`
+			Body   any    `json:"body"`
+		}{input.MealID, input.Body}
+
+		// Scaling is not idempotent: a retried "I ate half" would halve the meal again.
+		return withIdempotency(ctx, database, cfg.Idempotency.KeyTTL, "scale_meal", owner.UserID, input.IdempotencyKey, payload, func() (*ScaleMealResponse, error) {
+			resp := &ScaleMealResponse{}
+			err := database.WithTx(ctx, func(ctx context.Context, txDB *db.TxDatabase) error {
+				meal, err := txDB.MealLogRepository.GetByIDForUpdate(ctx, input.MealID)
+				if err != nil || meal == nil {
+					return huma.Error404NotFound(fmt.Sprintf("Meal '%s' not found", input.MealID))
+				}
+				recordedAt, err := time.Parse(time.RFC3339, meal.RecordedAt)
+				if err != nil {
+					return fmt.Errorf("failed to parse recorded_at of meal %s: %w", meal.ID, err)
+				}
+
+				macros, assumptions := portion.Scale(meal.Macros, meal.Assumptions, input.Body.Factor)
+				resp.Body.Meal, resp.Body.Revision, err = txDB.MealLogRepository.Revise(ctx, meal, models.MealRevisionKindScale, input.Body.Factor, macros, assumptions)
+				if err != nil {
+					return err
+				}
+				return txDB.NutritionRepository.Rebuild(ctx, meal.UserID, []time.Time{recordedAt})
+			})
+			if err != nil {
+				return nil, err
+			}
+			system := unitSystem(ctx, database, resp.Body.Meal.UserID)
+			renderMeals(system, resp.Body.Meal)
+			renderRevisions(system, resp.Body.Revision)
+			return resp, nil
+		})
+	})
+
+	huma.Get(mealsGroup, "/meal/{meal_id}/revisions", func(ctx context.Context, input *struct {
+		MealID string `path:"meal_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"Meal ID"`
+	}) (*ListMealRevisionsResponse, error) {
+		meal, err := database.MealLogRepository.GetByID(ctx, input.MealID)
+		if err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("Meal '%s' not found", input.MealID))
+		}
+
+		revisions, err := database.MealLogRepository.ListRevisions(ctx, input.MealID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list meal revisions: %w", err)
+		}
+		renderRevisions(unitSystem(ctx, database, meal.UserID), revisions...)
+
+		resp := &ListMealRevisionsResponse{}
+		resp.Body.Revisions = revisions
+		return resp, nil
+	})
+
+	huma.Get(mealsGroup, "/{user_id}/frequent", func(ctx context.Context, input *struct {
+		UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+		Days   int    `query:"days" default:"90" minimum:"1" maximum:"3650" doc:"Only count meals from the last this many days"`
+		Limit  int    `query:"limit" default:"10" minimum:"1" maximum:"50" doc:"Maximum number of foods to return"`
+	}) (*FrequentMealsResponse, error) {
+		since := time.Now().AddDate(0, 0, -input.Days)
+		meals, err := database.MealLogRepository.ListFrequent(ctx, input.UserID, since, input.Limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list frequent meals: %w", err)
+		}
+
+		resp := &FrequentMealsResponse{}
+		resp.Body.Meals = meals
+		return resp, nil
+	})
+
 	huma.Get(mealsGroup, "/meal/{meal_id}", func(ctx context.Context, input *struct {
 		MealID string `path:"meal_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"Meal ID"`
 	}) (*GetMealResponse, error) {
@@ -97,6 +397,7 @@ func RegisterMealEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 		if err != nil {
 			return nil, fmt.Errorf("failed to get meal: %w", err)
 		}
+		renderMeals(unitSystem(ctx, database, meal.UserID), meal)
 
 		resp := &GetMealResponse{}
 		resp.Body.Meal = meal
@@ -108,3 +409,28 @@ func parseDate(s string) time.Time {
 	t, _ := time.Parse("2006-01-02", s)
 	return t
 }
+
+// encodeSearchCursor encodes the position after a search result. The rank is
+// kept exactly, since the next page compares ranks for equality.
+func encodeSearchCursor(rank float64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(rank, 'g', -1, 64) + "|" + id))
+}
+
+func decodeSearchCursor(cursor string) (float64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", err
+	}
+	rawRank, id, ok := strings.Cut(string(data), "|")
+	if !ok {
+		return 0, "", errors.New("malformed cursor")
+	}
+	rank, err := strconv.ParseFloat(rawRank, 64)
+	if err != nil {
+		return 0, "", err
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return 0, "", err
+	}
+	return rank, id, nil
+}
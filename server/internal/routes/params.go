@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"reflect"
+
+	"github.com/danielgtaylor/huma/v2"
+)
+
+// OptionalParam is a path, query or header parameter that tells whether the
+// request sent it, for parameters whose zero value is meaningful. huma does
+// not support pointer parameters.
+type OptionalParam[T any] struct {
+	Value T
+	IsSet bool
+}
+
+// Schema documents the parameter as its value type.
+func (o OptionalParam[T]) Schema(r huma.Registry) *huma.Schema {
+	return huma.SchemaFromType(r, reflect.TypeFor[T]())
+}
+
+// Receiver is where huma parses the parameter into.
+func (o *OptionalParam[T]) Receiver() reflect.Value {
+	return reflect.ValueOf(o).Elem().Field(0)
+}
+
+// OnParamSet records whether the request sent the parameter.
+func (o *OptionalParam[T]) OnParamSet(isSet bool, parsed any) {
+	o.IsSet = isSet
+}
+
+// Ptr returns the value, or nil if the request did not send it.
+func (o OptionalParam[T]) Ptr() *T {
+	if !o.IsSet {
+		return nil
+	}
+	return &o.Value
+}
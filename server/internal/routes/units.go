@@ -0,0 +1,105 @@
+package routes
+
+import (
+	"context"
+	"strings"
+
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/units"
+)
+
+// unitSystem returns the unit system a user reads amounts in. Unknown users
+// read metric.
+func unitSystem(ctx context.Context, database *db.Database, userID string) units.System {
+	user, err := database.UserRepository.GetByID(ctx, userID)
+	if err != nil || user == nil {
+		return units.Metric
+	}
+	system, _ := units.ParseSystem(models.BodyProfileFromMetadata(user.Metadata).UnitSystem)
+	return system
+}
+
+// renderUsers fills in the profiles of users, rendered in their own unit
+// system.
+func renderUsers(users ...*models.User) {
+	for _, user := range users {
+		if user == nil {
+			continue
+		}
+		profile := models.BodyProfileFromMetadata(user.Metadata)
+		system, _ := units.ParseSystem(profile.UnitSystem)
+		user.Profile = &models.UserProfile{UnitSystem: string(system)}
+
+		if profile.WeightKg != nil {
+			to := units.Kilogram
+			if system == units.Imperial {
+				to = units.Pound
+			}
+			weight, _ := units.Quantity{Value: *profile.WeightKg, Unit: units.Kilogram}.Convert(to)
+			weight = weight.Round()
+			user.Profile.Weight = &models.Measurement{Value: weight.Value, Unit: to.Name, Text: weight.String()}
+		}
+		if profile.HeightCm != nil {
+			height := units.Quantity{Value: *profile.HeightCm, Unit: units.Centimeter}.In(system)
+			text := height.String()
+			if system == units.Imperial {
+				text, _ = units.FeetAndInches(height)
+			}
+			user.Profile.Height = &models.Measurement{Value: height.Value, Unit: height.Unit.Name, Text: text}
+		}
+	}
+}
+
+// renderMeals rewrites the assumptions of meals in system.
+func renderMeals(system units.System, meals ...*models.MealLog) {
+	for _, meal := range meals {
+		if meal != nil {
+			meal.Assumptions = renderAssumptions(system, meal.Assumptions)
+		}
+	}
+}
+
+// renderRevisions rewrites the assumptions of meal revisions in system.
+func renderRevisions(system units.System, revisions ...*models.MealRevision) {
+	for _, revision := range revisions {
+		if revision != nil {
+			revision.PreviousAssumptions = renderAssumptions(system, revision.PreviousAssumptions)
+			revision.Assumptions = renderAssumptions(system, revision.Assumptions)
+		}
+	}
+}
+
+// renderAssumptions returns assumptions with amounts of mass and volume in
+// the units of system, such as 150 g as 5.3 oz. Other amounts, such as
+// slices, are kept as they are, as is the text after the unit in "cup milk".
+// Volumes of foods with a known density, named after the unit or in the
+// field, also get their approximate weight.
+func renderAssumptions(system units.System, assumptions []models.Assumption) []models.Assumption {
+	if assumptions == nil {
+		return nil
+	}
+	rendered := make([]models.Assumption, len(assumptions))
+	for i, a := range assumptions {
+		rendered[i] = a
+		u, token, rest, ok := units.Parse(a.Unit)
+		if !ok || u.Dimension == units.Length {
+			continue
+		}
+		q := units.Quantity{Value: a.AssumedValue, Unit: u}
+		if u.Dimension == units.Volume {
+			food := rest + " " + strings.ReplaceAll(a.Field, "_", " ")
+			if mass, ok := units.ToMass(q, food); ok {
+				mass = mass.In(system)
+				rendered[i].Weight = &models.Measurement{Value: mass.Value, Unit: mass.Unit.Name, Text: mass.String()}
+			}
+		}
+		q = q.In(system)
+		if q.Unit != u {
+			token = q.Unit.Name
+		}
+		rendered[i].AssumedValue = q.Value
+		rendered[i].Unit = strings.TrimSpace(token + " " + rest)
+	}
+	return rendered
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/units"
+)
+
+func TestRenderAssumptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		system     units.System
+		assumption models.Assumption
+		wantValue  float64
+		wantUnit   string
+		wantWeight string
+	}{
+		{"grams in imperial", units.Imperial, models.Assumption{AssumedValue: 150, Unit: "g"}, 5.3, "oz", ""},
+		{"food after the unit", units.Metric, models.Assumption{AssumedValue: 1, Unit: "cup milk"}, 237, "ml milk", "244 g"},
+		{"food in the field", units.Imperial, models.Assumption{Field: "rice_volume", AssumedValue: 0.5, Unit: "cup"}, 0.5, "cup", "3.5 oz"},
+		{"unknown density", units.Metric, models.Assumption{Field: "sauce_volume", AssumedValue: 2, Unit: "tbsp"}, 30, "ml", ""},
+		{"other units", units.Imperial, models.Assumption{AssumedValue: 2, Unit: "slices"}, 2, "slices", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := renderAssumptions(tt.system, []models.Assumption{tt.assumption})[0]
+			if got.AssumedValue != tt.wantValue || got.Unit != tt.wantUnit {
+				t.Errorf("rendered = %v %q, want %v %q", got.AssumedValue, got.Unit, tt.wantValue, tt.wantUnit)
+			}
+			weight := ""
+			if got.Weight != nil {
+				weight = got.Weight.Text
+			}
+			if weight != tt.wantWeight {
+				t.Errorf("weight = %q, want %q", weight, tt.wantWeight)
+			}
+		})
+	}
+}
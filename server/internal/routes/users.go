@@ -2,11 +2,22 @@ package routes
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
 
 	"github.com/danielgtaylor/huma/v2"
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/api"
+	"github.com/simhozebs/mugo/internal/config"
 	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/jobs"
+	"github.com/simhozebs/mugo/internal/logging"
 	"github.com/simhozebs/mugo/internal/models"
+	"github.com/simhozebs/mugo/internal/units"
 )
 
 type CreateUserRequest struct {
@@ -34,8 +45,41 @@ type ListUsersResponse struct {
 	}
 }
 
+type UpdateDietaryProfileRequest struct {
+	UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Body   models.DietaryProfile
+}
+
+type UpdateBodyProfileRequest struct {
+	UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	Body   struct {
+		UnitSystem string   `json:"unit_system" enum:"metric,imperial" doc:"Unit system amounts are shown in; weight and height are given in it"`
+		Weight     *float64 `json:"weight,omitempty" exclusiveMinimum:"0" maximum:"1500" example:"70" doc:"Body weight in kg, or lb in imperial; omit if unknown"`
+		Height     *float64 `json:"height,omitempty" exclusiveMinimum:"0" maximum:"300" example:"175" doc:"Height in cm, or inches in imperial; omit if unknown"`
+	}
+}
+
+type GetUsageRequest struct {
+	UserID    string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	StartDate string `query:"start_date" format:"date" example:"2025-01-01" doc:"First UTC day (YYYY-MM-DD), defaults to 29 days before end_date"`
+	EndDate   string `query:"end_date" format:"date" example:"2025-01-30" doc:"Last UTC day (YYYY-MM-DD), defaults to today"`
+}
+
+type GetUsageResponse struct {
+	Body struct {
+		UserID    string               `json:"user_id"`
+		StartDate string               `json:"start_date"`
+		EndDate   string               `json:"end_date"`
+		Days      []*models.DailyUsage `json:"days" doc:"Days with usage, oldest first"`
+		Totals    models.UsageTotals   `json:"totals"`
+	}
+}
+
+// maxUsageDays bounds the range of a usage report.
+const maxUsageDays = 366
+
 // RegisterUserEndpoints registers user management endpoints.
-func RegisterUserEndpoints(humaAPI huma.API, prefix string, database *db.Database) {
+func RegisterUserEndpoints(humaAPI huma.API, prefix string, cfg *config.Config, database *db.Database) {
 	usersGroup := huma.NewGroup(humaAPI, prefix)
 
 	huma.Post(usersGroup, "", func(ctx context.Context, input *CreateUserRequest) (*CreateUserResponse, error) {
@@ -53,6 +97,7 @@ func RegisterUserEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 		}
 
 		resp := &CreateUserResponse{}
+		renderUsers(user)
 		resp.Body.User = user
 		return resp, nil
 	})
@@ -64,6 +109,7 @@ func RegisterUserEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 		}
 
 		resp := &ListUsersResponse{}
+		renderUsers(users...)
 		resp.Body.Users = users
 		return resp, nil
 	})
@@ -77,10 +123,137 @@ func RegisterUserEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 		}
 
 		resp := &GetUserResponse{}
+		renderUsers(user)
+		resp.Body.User = user
+		return resp, nil
+	})
+
+	huma.Register(usersGroup, huma.Operation{
+		OperationID:   "delete-user",
+		Method:        http.MethodDelete,
+		Path:          "/{user_id}",
+		Summary:       "Erase a user and all of their data",
+		Description:   "Enqueues an erasure job that deletes the user's agent sessions and every row stored about the user, leaving a tombstone. Poll the job for the outcome.",
+		DefaultStatus: http.StatusAccepted,
+	}, func(ctx context.Context, input *struct {
+		UserID string `path:"user_id" example:"550e8400-e29b-41d4-a716-446655440000" doc:"User ID"`
+	}) (*api.JobResponse, error) {
+		if _, err := database.UserRepository.GetByID(ctx, input.UserID); err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("User '%s' not found", input.UserID))
+		}
+
+		job, err := database.JobRepository.Enqueue(ctx,
+			models.JobKindUserErasure,
+			input.UserID,
+			map[string]string{"user_id": input.UserID},
+			cfg.Jobs.MaxAttempts,
+			"",
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to enqueue user erasure: %w", err)
+		}
+
+		resp := &api.JobResponse{}
+		resp.Body.Job = job
+		return resp, nil
+	})
+
+	huma.Put(usersGroup, "/{user_id}/dietary-profile", func(ctx context.Context, input *UpdateDietaryProfileRequest) (*GetUserResponse, error) {
+		user, err := database.UserRepository.GetByID(ctx, input.UserID)
+		if err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("User '%s' not found", input.UserID))
+		}
+
+		metadata := user.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metadata[models.MetadataKeyDietaryPreferences] = input.Body.Preferences
+		metadata[models.MetadataKeyAllergens] = input.Body.Allergens
+
+		user, err = database.UserRepository.UpdateMetadata(ctx, input.UserID, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update dietary profile: %w", err)
+		}
+
+		resp := &GetUserResponse{}
+		renderUsers(user)
+		resp.Body.User = user
+		return resp, nil
+	})
+
+	huma.Put(usersGroup, "/{user_id}/body-profile", func(ctx context.Context, input *UpdateBodyProfileRequest) (*GetUserResponse, error) {
+		user, err := database.UserRepository.GetByID(ctx, input.UserID)
+		if err != nil {
+			return nil, huma.Error404NotFound(fmt.Sprintf("User '%s' not found", input.UserID))
+		}
+
+		weightUnit, heightUnit := units.Kilogram, units.Centimeter
+		if input.Body.UnitSystem == string(units.Imperial) {
+			weightUnit, heightUnit = units.Pound, units.Inch
+		}
+
+		// Weight and height are stored in metric, so changing the unit
+		// system later does not change them.
+		metadata := user.Metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metadata[models.MetadataKeyUnitSystem] = input.Body.UnitSystem
+		delete(metadata, models.MetadataKeyWeightKg)
+		if input.Body.Weight != nil {
+			weight, _ := units.Quantity{Value: *input.Body.Weight, Unit: weightUnit}.Convert(units.Kilogram)
+			metadata[models.MetadataKeyWeightKg] = weight.Value
+		}
+		delete(metadata, models.MetadataKeyHeightCm)
+		if input.Body.Height != nil {
+			height, _ := units.Quantity{Value: *input.Body.Height, Unit: heightUnit}.Convert(units.Centimeter)
+			metadata[models.MetadataKeyHeightCm] = height.Value
+		}
+
+		user, err = database.UserRepository.UpdateMetadata(ctx, input.UserID, metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update body profile: %w", err)
+		}
+
+		resp := &GetUserResponse{}
+		renderUsers(user)
 		resp.Body.User = user
 		return resp, nil
 	})
 
+	huma.Get(usersGroup, "/{user_id}/usage", func(ctx context.Context, input *GetUsageRequest) (*GetUsageResponse, error) {
+		end := time.Now().UTC().Truncate(24 * time.Hour)
+		if input.EndDate != "" {
+			end = parseDate(input.EndDate)
+		}
+		start := end.AddDate(0, 0, -29)
+		if input.StartDate != "" {
+			start = parseDate(input.StartDate)
+		}
+		if end.Before(start) {
+			return nil, huma.Error422UnprocessableEntity("end_date must not be before start_date")
+		}
+		if end.Sub(start) >= maxUsageDays*24*time.Hour {
+			return nil, huma.Error422UnprocessableEntity(fmt.Sprintf("The range must not exceed %d days", maxUsageDays))
+		}
+
+		days, err := database.UsageRepository.ListDaily(ctx, input.UserID, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get usage: %w", err)
+		}
+
+		resp := &GetUsageResponse{}
+		resp.Body.UserID = input.UserID
+		resp.Body.StartDate = start.Format("2006-01-02")
+		resp.Body.EndDate = end.Format("2006-01-02")
+		resp.Body.Days = days
+		for _, day := range days {
+			resp.Body.Totals.Add(day.UsageTotals)
+		}
+		return resp, nil
+	})
+
 	huma.Get(usersGroup, "/by-username/{username}", func(ctx context.Context, input *struct {
 		Username string `path:"username" example:"johndoe" doc:"Username"`
 	}) (*GetUserResponse, error) {
@@ -90,7 +263,73 @@ func RegisterUserEndpoints(humaAPI huma.API, prefix string, database *db.Databas
 		}
 
 		resp := &GetUserResponse{}
+		renderUsers(user)
 		resp.Body.User = user
 		return resp, nil
 	})
 }
+
+// UserErasureJobHandler returns the job handler that erases a user. It
+// deletes the user's sessions from the agent runner first, since a failure
+// there can be retried while the user still exists, then every row of the
+// user in one transaction, which also records the tombstone. The tombstone
+// is the job's result. Meals are logged from text only, so there is no
+// stored media to purge.
+func UserErasureJobHandler(cfg *config.Config, agentRunner adk.AgentRunner, database *db.Database) jobs.Handler {
+	return func(ctx context.Context, job *models.Job) (any, error) {
+		logger := logging.FromContext(ctx)
+
+		// An earlier attempt may have erased the user but failed to record the outcome.
+		erasure, err := database.ErasureRepository.GetByUser(ctx, job.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if erasure != nil {
+			return erasure, nil
+		}
+
+		sessionsDeleted, err := deleteUserSessions(ctx, cfg, agentRunner, job.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		err = database.WithTx(ctx, func(ctx context.Context, txDB *db.TxDatabase) error {
+			erasure, err = txDB.ErasureRepository.Erase(ctx, job.UserID, job.ID, sessionsDeleted)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		if erasure == nil {
+			return nil, fmt.Errorf("user %s not found", job.UserID)
+		}
+
+		logger.Info("User erased", "user_id", job.UserID, "erased", erasure.Erased, "adk_sessions_deleted", sessionsDeleted)
+		return erasure, nil
+	}
+}
+
+// deleteUserSessions deletes every session of the user from every agent app
+// and returns how many it deleted. Sessions are listed from the agent runner
+// rather than taken from conversations, which only cover sessions of users
+// that existed when the session was created.
+func deleteUserSessions(ctx context.Context, cfg *config.Config, agentRunner adk.AgentRunner, userID string) (int, error) {
+	deleted := 0
+	for _, app := range slices.Compact(slices.Sorted(maps.Values(cfg.Agents.Mapping))) {
+		sessions, err := agentRunner.ListSessions(ctx, app, userID)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list sessions of app %s: %w", app, err)
+		}
+		for _, session := range sessions {
+			err := agentRunner.DeleteSession(ctx, app, userID, session.ID)
+			if errors.Is(err, adk.ErrSessionNotFound) {
+				continue
+			}
+			if err != nil {
+				return deleted, fmt.Errorf("failed to delete session %s of app %s: %w", session.ID, app, err)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
@@ -0,0 +1,96 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/simhozebs/mugo/internal/adk"
+	"github.com/simhozebs/mugo/internal/config"
+	"google.golang.org/adk/server/restapi/models"
+)
+
+// stubRunner keeps sessions by app and user. Its other methods are not
+// implemented.
+type stubRunner struct {
+	adk.AgentRunner
+	sessions map[string]map[string][]string
+	listErr  error
+	deleted  []string
+}
+
+func (r *stubRunner) ListSessions(ctx context.Context, appName, userID string) ([]models.Session, error) {
+	if r.listErr != nil {
+		return nil, r.listErr
+	}
+	var sessions []models.Session
+	for _, id := range r.sessions[appName][userID] {
+		sessions = append(sessions, models.Session{ID: id, AppName: appName, UserID: userID})
+	}
+	return sessions, nil
+}
+
+func (r *stubRunner) DeleteSession(ctx context.Context, appName, userID, sessionID string) error {
+	ids := r.sessions[appName][userID]
+	i := slices.Index(ids, sessionID)
+	if i < 0 {
+		return adk.ErrSessionNotFound
+	}
+	r.sessions[appName][userID] = slices.Delete(ids, i, i+1)
+	r.deleted = append(r.deleted, appName+"/"+sessionID)
+	return nil
+}
+
+func TestDeleteUserSessions(t *testing.T) {
+	cfg := &config.Config{Agents: config.AgentsConfig{Mapping: map[string]string{
+		"nutrition": "nutrition_agent",
+		"weather":   "weather_agent",
+		"estimate":  "nutrition_agent",
+	}}}
+
+	t.Run("deletes every session of the user", func(t *testing.T) {
+		runner := &stubRunner{sessions: map[string]map[string][]string{
+			"nutrition_agent": {
+				"u1": {"s1", "s2"},
+				"u2": {"s3"},
+			},
+			// Sessions started outside a conversation have no row to find them by.
+			"weather_agent": {"u1": {"w1"}},
+			"other_agent":   {"u1": {"o1"}},
+		}}
+
+		deleted, err := deleteUserSessions(context.Background(), cfg, runner, "u1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if deleted != 3 {
+			t.Errorf("deleted = %d, want 3", deleted)
+		}
+		want := []string{"nutrition_agent/s1", "nutrition_agent/s2", "weather_agent/w1"}
+		if !slices.Equal(runner.deleted, want) {
+			t.Errorf("deleted sessions = %v, want %v", runner.deleted, want)
+		}
+		if got := runner.sessions["nutrition_agent"]["u2"]; !slices.Equal(got, []string{"s3"}) {
+			t.Errorf("sessions of u2 = %v, want [s3]", got)
+		}
+		if got := runner.sessions["other_agent"]["u1"]; !slices.Equal(got, []string{"o1"}) {
+			t.Errorf("sessions of unmapped app = %v, want [o1]", got)
+		}
+	})
+
+	t.Run("no sessions", func(t *testing.T) {
+		deleted, err := deleteUserSessions(context.Background(), cfg, &stubRunner{}, "u1")
+		if err != nil || deleted != 0 {
+			t.Fatalf("deleteUserSessions = %d, %v; want 0, nil", deleted, err)
+		}
+	})
+
+	t.Run("list failure", func(t *testing.T) {
+		listErr := errors.New("adk server unavailable")
+		_, err := deleteUserSessions(context.Background(), cfg, &stubRunner{listErr: listErr}, "u1")
+		if !errors.Is(err, listErr) {
+			t.Fatalf("deleteUserSessions error = %v, want %v", err, listErr)
+		}
+	})
+}
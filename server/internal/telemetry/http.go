@@ -0,0 +1,38 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/danielgtaylor/huma/v2"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a span for every request and continues the trace of the
+// caller, if any. Spans are named after the matched route, so requests to the
+// same route group together.
+func Middleware(serviceName string) func(http.Handler) http.Handler {
+	return otelhttp.NewMiddleware(serviceName, otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
+		if r.Pattern == "" {
+			return operation
+		}
+		return r.Method + " " + r.Pattern
+	}))
+}
+
+// Transport injects the trace context into outgoing requests and traces them.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
+
+// HumaMiddleware tags the request span with the huma operation that serves it.
+func HumaMiddleware(ctx huma.Context, next func(huma.Context)) {
+	if op := ctx.Operation(); op != nil {
+		trace.SpanFromContext(ctx.Context()).SetAttributes(
+			attribute.String("http.route", op.Path),
+			attribute.String("huma.operation_id", op.OperationID),
+		)
+	}
+	next(ctx)
+}
@@ -0,0 +1,55 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// The instruments are bound to the global meter provider, so they can be used
+// before Setup runs. Creation only fails on invalid names, and the global
+// meter then still returns a usable no-op instrument, so errors are ignored.
+var (
+	meter = otel.Meter(instrumentationName)
+
+	agentDuration, _ = meter.Float64Histogram("mugo.agent.duration",
+		metric.WithDescription("Duration of agent runs"),
+		metric.WithUnit("s"),
+	)
+	tokenUsage, _ = meter.Int64Counter("mugo.llm.tokens",
+		metric.WithDescription("Tokens used by LLM calls"),
+		metric.WithUnit("{token}"),
+	)
+	estimateFailures, _ = meter.Int64Counter("mugo.estimate.failures",
+		metric.WithDescription("Nutrition estimates that produced no result"),
+		metric.WithUnit("{estimate}"),
+	)
+)
+
+// RecordAgentRun records the duration and outcome of an agent run.
+func RecordAgentRun(ctx context.Context, appName string, duration time.Duration, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "error"
+	}
+	agentDuration.Record(ctx, duration.Seconds(), metric.WithAttributes(
+		attribute.String("agent.app", appName),
+		attribute.String("outcome", outcome),
+	))
+}
+
+// RecordTokenUsage records the tokens one LLM call consumed.
+func RecordTokenUsage(ctx context.Context, modelName string, inputTokens, outputTokens int32) {
+	model := attribute.String("llm.model", modelName)
+	tokenUsage.Add(ctx, int64(inputTokens), metric.WithAttributes(model, attribute.String("token.type", "input")))
+	tokenUsage.Add(ctx, int64(outputTokens), metric.WithAttributes(model, attribute.String("token.type", "output")))
+}
+
+// RecordEstimateFailure records a failed nutrition estimate; reason is a short
+// fixed label such as "agent" or "parse".
+func RecordEstimateFailure(ctx context.Context, reason string) {
+	estimateFailures.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", reason)))
+}
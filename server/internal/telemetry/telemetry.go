@@ -0,0 +1,115 @@
+// Package telemetry sets up OpenTelemetry tracing and metrics and defines the
+// instruments the application records.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/simhozebs/mugo/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	adktelemetry "google.golang.org/adk/telemetry"
+)
+
+const instrumentationName = "github.com/simhozebs/mugo"
+
+// Tracer creates the application's own spans. It is bound to the global
+// tracer provider, so it can be used before Setup runs.
+var Tracer trace.Tracer = otel.Tracer(instrumentationName)
+
+// Setup installs the global tracer and meter providers for the service and
+// returns a function that flushes and stops them.
+//
+// Trace context is always propagated, so traces stay connected across
+// services even when this one does not export. Spans ADK creates for agent
+// and model calls are exported along with ours.
+func Setup(ctx context.Context, serviceName string, cfg config.TelemetryConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter := cfg.Exporter
+	if exporter == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithTelemetrySDK(),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create telemetry resource: %w", err)
+	}
+
+	spanExporter, err := newSpanExporter(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+	// ADK traces through its own provider, so it needs an exporter of its own.
+	adkSpanExporter, err := newSpanExporter(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+	metricExporter, err := newMetricExporter(ctx, exporter)
+	if err != nil {
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	adkSpanProcessor := sdktrace.NewBatchSpanProcessor(adkSpanExporter)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
+		sdkmetric.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	adktelemetry.RegisterSpanProcessor(adkSpanProcessor)
+
+	return func(ctx context.Context) error {
+		return errors.Join(
+			tracerProvider.Shutdown(ctx),
+			adkSpanProcessor.Shutdown(ctx),
+			meterProvider.Shutdown(ctx),
+		)
+	}, nil
+}
+
+func newSpanExporter(ctx context.Context, exporter string) (sdktrace.SpanExporter, error) {
+	switch exporter {
+	case "otlp":
+		return otlptracehttp.New(ctx)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown telemetry exporter %q", exporter)
+	}
+}
+
+func newMetricExporter(ctx context.Context, exporter string) (sdkmetric.Exporter, error) {
+	switch exporter {
+	case "otlp":
+		return otlpmetrichttp.New(ctx)
+	case "stdout":
+		return stdoutmetric.New(stdoutmetric.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown telemetry exporter %q", exporter)
+	}
+}
@@ -0,0 +1,164 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/simhozebs/mugo/internal/db"
+	"github.com/simhozebs/mugo/internal/models"
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// DateRangeArgs selects an inclusive range of days.
+type DateRangeArgs struct {
+	StartDate string `json:"start_date" jsonschema:"first day of the range (YYYY-MM-DD)"`
+	EndDate   string `json:"end_date" jsonschema:"last day of the range, inclusive (YYYY-MM-DD)"`
+}
+
+// WeekArgs selects a single week by its start date.
+type WeekArgs struct {
+	WeekStartDate string `json:"week_start_date" jsonschema:"Monday the week starts on (YYYY-MM-DD)"`
+}
+
+// MealLogsResponse lists the meals logged in a date range.
+type MealLogsResponse struct {
+	Meals []MealLogEntry `json:"meals"`
+	Error string         `json:"error,omitempty"`
+}
+
+// MealLogEntry is the part of a meal log the coach needs to reason about.
+type MealLogEntry struct {
+	FoodName   string        `json:"food_name"`
+	MealType   string        `json:"meal_type"`
+	RecordedAt string        `json:"recorded_at"`
+	Macros     models.Macros `json:"macros"`
+}
+
+// DailySummariesResponse lists the daily totals in a date range.
+type DailySummariesResponse struct {
+	Days  []*models.DailyNutritionSummary `json:"days"`
+	Error string                          `json:"error,omitempty"`
+}
+
+// WeeklySummaryResponse holds the totals and averages for one week.
+type WeeklySummaryResponse struct {
+	Summary *models.WeeklyNutritionSummary `json:"summary,omitempty"`
+	Error   string                         `json:"error,omitempty"`
+}
+
+// UserProfileResponse holds the user's stored profile metadata.
+type UserProfileResponse struct {
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// CoachTools creates the tools the nutrition coach uses to read a user's history.
+// Every tool is scoped to the user of the current session, never to an ID chosen by the model.
+func CoachTools(database *db.Database) ([]tool.Tool, error) {
+	getMealLogs, err := functiontool.New(
+		functiontool.Config{
+			Name:        "get_meal_logs",
+			Description: "Lists every meal the user logged between start_date and end_date, with macros.",
+		},
+		func(ctx tool.Context, args DateRangeArgs) MealLogsResponse {
+			start, end, err := parseRange(args.StartDate, args.EndDate)
+			if err != nil {
+				return MealLogsResponse{Error: err.Error()}
+			}
+			meals, err := database.MealLogRepository.ListByUserAndDateRange(ctx, ctx.UserID(), start, end.AddDate(0, 0, 1))
+			if err != nil {
+				return MealLogsResponse{Error: fmt.Sprintf("Error listing meals: %v", err)}
+			}
+			resp := MealLogsResponse{Meals: make([]MealLogEntry, len(meals))}
+			for i, m := range meals {
+				resp.Meals[i] = MealLogEntry{
+					FoodName:   m.FoodName,
+					MealType:   m.MealType,
+					RecordedAt: m.RecordedAt,
+					Macros:     m.Macros,
+				}
+			}
+			return resp
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	getDailySummaries, err := functiontool.New(
+		functiontool.Config{
+			Name:        "get_daily_summaries",
+			Description: "Lists the user's daily calorie and macro totals between start_date and end_date.",
+		},
+		func(ctx tool.Context, args DateRangeArgs) DailySummariesResponse {
+			start, end, err := parseRange(args.StartDate, args.EndDate)
+			if err != nil {
+				return DailySummariesResponse{Error: err.Error()}
+			}
+			days, err := database.NutritionRepository.ListDailyByDateRange(ctx, ctx.UserID(), start, end)
+			if err != nil {
+				return DailySummariesResponse{Error: fmt.Sprintf("Error listing daily summaries: %v", err)}
+			}
+			return DailySummariesResponse{Days: days}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	getWeeklySummary, err := functiontool.New(
+		functiontool.Config{
+			Name:        "get_weekly_summary",
+			Description: "Returns the user's weekly totals and daily averages for the week starting on week_start_date.",
+		},
+		func(ctx tool.Context, args WeekArgs) WeeklySummaryResponse {
+			weekStart, err := time.Parse("2006-01-02", args.WeekStartDate)
+			if err != nil {
+				return WeeklySummaryResponse{Error: fmt.Sprintf("Invalid week_start_date: %v", err)}
+			}
+			summary, err := database.NutritionRepository.GetWeekly(ctx, ctx.UserID(), weekStart)
+			if err != nil {
+				return WeeklySummaryResponse{Error: fmt.Sprintf("No weekly summary available: %v", err)}
+			}
+			return WeeklySummaryResponse{Summary: summary}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	getUserProfile, err := functiontool.New(
+		functiontool.Config{
+			Name:        "get_user_profile",
+			Description: "Returns the user's profile metadata, such as body weight, height and goals.",
+		},
+		func(ctx tool.Context, args struct{}) UserProfileResponse {
+			user, err := database.UserRepository.GetByID(ctx, ctx.UserID())
+			if err != nil {
+				return UserProfileResponse{Error: fmt.Sprintf("Error reading profile: %v", err)}
+			}
+			return UserProfileResponse{Metadata: user.Metadata}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return []tool.Tool{getMealLogs, getDailySummaries, getWeeklySummary, getUserProfile}, nil
+}
+
+func parseRange(startDate, endDate string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid start_date: %w", err)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid end_date: %w", err)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("end_date %s is before start_date %s", endDate, startDate)
+	}
+	return start, end, nil
+}
@@ -0,0 +1,94 @@
+package units
+
+import (
+	"regexp"
+	"strings"
+)
+
+// densities are the grams per milliliter of common foods, as measured in the
+// kitchen: dry ingredients spooned into the cup, not packed. Foods are
+// matched by the words of their name, so more specific names come first.
+var densities = []struct {
+	names      []string
+	gramsPerML float64
+}{
+	{[]string{"peanut butter", "almond butter", "nut butter"}, 1.08},
+	{[]string{"olive oil"}, 0.91},
+	{[]string{"oil"}, 0.92},
+	{[]string{"butter", "ghee"}, 0.91},
+	{[]string{"heavy cream", "whipping cream"}, 0.99},
+	{[]string{"sour cream", "cream cheese"}, 1.02},
+	{[]string{"milk", "buttermilk", "cream"}, 1.03},
+	{[]string{"yogurt", "yoghurt", "kefir"}, 1.05},
+	{[]string{"honey"}, 1.42},
+	{[]string{"maple syrup", "syrup", "molasses"}, 1.33},
+	{[]string{"brown sugar"}, 0.93},
+	{[]string{"powdered sugar", "icing sugar"}, 0.51},
+	{[]string{"sugar"}, 0.85},
+	{[]string{"flour"}, 0.53},
+	{[]string{"cocoa"}, 0.42},
+	{[]string{"cooked rice"}, 0.66},
+	{[]string{"rice"}, 0.85},
+	{[]string{"rolled oats", "oats"}, 0.41},
+	{[]string{"oatmeal", "porridge"}, 1.0},
+	{[]string{"granola", "muesli"}, 0.45},
+	{[]string{"cereal"}, 0.15},
+	{[]string{"salt"}, 1.2},
+	{[]string{"mayonnaise", "mayo"}, 0.91},
+	{[]string{"ketchup", "tomato sauce"}, 1.15},
+	{[]string{"salsa"}, 1.05},
+	{[]string{"hummus"}, 1.05},
+	{[]string{"shredded cheese", "grated cheese"}, 0.45},
+	{[]string{"almonds", "nuts", "walnuts", "peanuts", "cashews"}, 0.6},
+	{[]string{"berries", "blueberries", "raspberries", "strawberries"}, 0.6},
+	{[]string{"beans", "lentils", "chickpeas"}, 0.75},
+	{[]string{"pasta"}, 0.55},
+	{[]string{"juice", "smoothie"}, 1.04},
+	{[]string{"soda", "cola"}, 1.04},
+	{[]string{"broth", "stock", "soup"}, 1.0},
+	{[]string{"water", "coffee", "tea"}, 1.0},
+}
+
+var densityPatterns = func() []*regexp.Regexp {
+	patterns := make([]*regexp.Regexp, len(densities))
+	for i, d := range densities {
+		quoted := make([]string, len(d.names))
+		for j, name := range d.names {
+			quoted[j] = regexp.QuoteMeta(name)
+		}
+		patterns[i] = regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	return patterns
+}()
+
+// Density returns the grams per milliliter of a food, found by the words of
+// its name, such as "cup milk" or "Oatmeal with banana". It reports false
+// for foods the table does not know.
+func Density(food string) (float64, bool) {
+	food = strings.ToLower(food)
+	for i, pattern := range densityPatterns {
+		if pattern.MatchString(food) {
+			return densities[i].gramsPerML, true
+		}
+	}
+	return 0, false
+}
+
+// ToMass returns the grams a volume of food weighs. It returns q as it is if
+// q is a mass already, and reports false if q is neither or the density of
+// the food is unknown.
+func ToMass(q Quantity, food string) (Quantity, bool) {
+	switch q.Unit.Dimension {
+	case Mass:
+		g, _ := q.Convert(Gram)
+		return g, true
+	case Volume:
+		density, ok := Density(food)
+		if !ok {
+			return Quantity{}, false
+		}
+		ml, _ := q.Convert(Milliliter)
+		return Quantity{Value: ml.Value * density, Unit: Gram}, true
+	}
+	return Quantity{}, false
+}
@@ -0,0 +1,272 @@
+// Package units converts amounts between units of mass, volume and length,
+// and renders them in a user's metric or imperial system. Volumes use US
+// customary kitchen measures.
+package units
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Dimension is what a unit measures.
+type Dimension string
+
+const (
+	Mass   Dimension = "mass"
+	Volume Dimension = "volume"
+	Length Dimension = "length"
+)
+
+// System is the unit system a user reads amounts in.
+type System string
+
+const (
+	Metric   System = "metric"
+	Imperial System = "imperial"
+)
+
+// ParseSystem reads a unit system, reporting whether s names one.
+func ParseSystem(s string) (System, bool) {
+	switch System(strings.ToLower(strings.TrimSpace(s))) {
+	case Metric:
+		return Metric, true
+	case Imperial:
+		return Imperial, true
+	}
+	return "", false
+}
+
+// Unit is a unit of measure. Units of a dimension convert into each other
+// through the amount of the dimension's base unit in one of them: grams,
+// milliliters or centimeters.
+type Unit struct {
+	Name      string
+	Dimension Dimension
+	base      float64
+	step      float64
+}
+
+var (
+	Milligram  = Unit{Name: "mg", Dimension: Mass, base: 0.001, step: 1}
+	Gram       = Unit{Name: "g", Dimension: Mass, base: 1, step: 1}
+	Kilogram   = Unit{Name: "kg", Dimension: Mass, base: 1000, step: 0.1}
+	Ounce      = Unit{Name: "oz", Dimension: Mass, base: 28.349523125, step: 0.1}
+	Pound      = Unit{Name: "lb", Dimension: Mass, base: 453.59237, step: 0.1}
+	Milliliter = Unit{Name: "ml", Dimension: Volume, base: 1, step: 1}
+	Liter      = Unit{Name: "l", Dimension: Volume, base: 1000, step: 0.1}
+	Teaspoon   = Unit{Name: "tsp", Dimension: Volume, base: 4.92892159375, step: 0.25}
+	Tablespoon = Unit{Name: "tbsp", Dimension: Volume, base: 14.78676478125, step: 0.5}
+	FluidOunce = Unit{Name: "fl oz", Dimension: Volume, base: 29.5735295625, step: 0.1}
+	Cup        = Unit{Name: "cup", Dimension: Volume, base: 236.5882365, step: 0.25}
+	Centimeter = Unit{Name: "cm", Dimension: Length, base: 1, step: 1}
+	Meter      = Unit{Name: "m", Dimension: Length, base: 100, step: 0.01}
+	Inch       = Unit{Name: "in", Dimension: Length, base: 2.54, step: 1}
+	Foot       = Unit{Name: "ft", Dimension: Length, base: 30.48, step: 0.1}
+)
+
+var aliases = map[string]Unit{
+	"mg":          Milligram,
+	"milligram":   Milligram,
+	"milligrams":  Milligram,
+	"g":           Gram,
+	"gram":        Gram,
+	"grams":       Gram,
+	"kg":          Kilogram,
+	"kilogram":    Kilogram,
+	"kilograms":   Kilogram,
+	"oz":          Ounce,
+	"ounce":       Ounce,
+	"ounces":      Ounce,
+	"lb":          Pound,
+	"lbs":         Pound,
+	"pound":       Pound,
+	"pounds":      Pound,
+	"ml":          Milliliter,
+	"milliliter":  Milliliter,
+	"milliliters": Milliliter,
+	"millilitre":  Milliliter,
+	"millilitres": Milliliter,
+	"l":           Liter,
+	"liter":       Liter,
+	"liters":      Liter,
+	"litre":       Liter,
+	"litres":      Liter,
+	"tsp":         Teaspoon,
+	"teaspoon":    Teaspoon,
+	"teaspoons":   Teaspoon,
+	"tbsp":        Tablespoon,
+	"tablespoon":  Tablespoon,
+	"tablespoons": Tablespoon,
+	"fl oz":       FluidOunce,
+	"floz":        FluidOunce,
+	"cup":         Cup,
+	"cups":        Cup,
+	"cm":          Centimeter,
+	"centimeter":  Centimeter,
+	"centimeters": Centimeter,
+	"m":           Meter,
+	"meter":       Meter,
+	"meters":      Meter,
+	"in":          Inch,
+	"inch":        Inch,
+	"inches":      Inch,
+	"ft":          Foot,
+	"foot":        Foot,
+	"feet":        Foot,
+}
+
+// kitchenMeasures are the spoon and cup measures, largest first, with the
+// smallest amount in milliliters each reads well for: a quarter cup, then one
+// tablespoon.
+var kitchenMeasures = []struct {
+	unit Unit
+	min  float64
+}{
+	{Cup, Cup.base / 4},
+	{Tablespoon, Tablespoon.base},
+	{Teaspoon, 0},
+}
+
+// ErrIncompatible is returned when converting between units of different
+// dimensions.
+var ErrIncompatible = errors.New("units measure different dimensions")
+
+// Lookup returns the unit named name, such as "g", "grams" or "fl oz".
+func Lookup(name string) (Unit, bool) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	u, ok := aliases[strings.TrimSuffix(name, ".")]
+	return u, ok
+}
+
+// Parse reads the unit a free-text unit such as "cup milk" starts with. It
+// returns the words naming the unit as written and the rest of the text.
+func Parse(s string) (u Unit, token, rest string, ok bool) {
+	words := strings.Fields(s)
+	for n := min(2, len(words)); n > 0; n-- {
+		if u, ok := Lookup(strings.Join(words[:n], " ")); ok {
+			return u, strings.Join(words[:n], " "), strings.Join(words[n:], " "), true
+		}
+	}
+	return Unit{}, "", "", false
+}
+
+// IsKitchenMeasure reports whether u is a teaspoon, tablespoon or cup.
+func (u Unit) IsKitchenMeasure() bool {
+	return u == Teaspoon || u == Tablespoon || u == Cup
+}
+
+// Quantity is an amount in a unit.
+type Quantity struct {
+	Value float64
+	Unit  Unit
+}
+
+// Convert returns q in the unit to.
+func (q Quantity) Convert(to Unit) (Quantity, error) {
+	if q.Unit.Dimension != to.Dimension {
+		return Quantity{}, fmt.Errorf("cannot convert %s to %s: %w", q.Unit.Name, to.Name, ErrIncompatible)
+	}
+	return Quantity{Value: q.Value * q.Unit.base / to.base, Unit: to}, nil
+}
+
+// Round rounds q to the precision its unit is shown with: whole grams,
+// milliliters and centimeters, tenths of larger units and kitchen fractions
+// of spoons and cups. Amounts under ten base units keep a decimal.
+func (q Quantity) Round() Quantity {
+	step := q.Unit.step
+	if step == 1 && math.Abs(q.Value) < 10 {
+		step = 0.1
+	}
+	// Dividing by the inverse of step keeps decimal steps free of float noise.
+	q.Value = math.Round(q.Value/step) / (1 / step)
+	return q
+}
+
+// Kitchen returns a volume in the spoon or cup measure it reads best in,
+// rounded to a kitchen fraction, so half of a quarter cup is 2 tbsp. A
+// positive amount is at least the smallest fraction, a pinch being 1/4 tsp.
+func (q Quantity) Kitchen() (Quantity, error) {
+	ml, err := q.Convert(Milliliter)
+	if err != nil {
+		return Quantity{}, err
+	}
+	for _, m := range kitchenMeasures {
+		if ml.Value >= m.min {
+			k, _ := ml.Convert(m.unit)
+			k = k.Round()
+			if ml.Value > 0 {
+				k.Value = max(k.Value, m.unit.step)
+			}
+			return k, nil
+		}
+	}
+	// Negative amounts are not kitchen measures.
+	return ml.Round(), nil
+}
+
+// In returns q in the unit of system it reads best in, rounded: grams or
+// kilograms and milliliters or liters in metric; ounces or pounds in
+// imperial. Imperial volumes are fluid ounces, except spoon and cup measures
+// and amounts under a quarter cup, which are spoons or cups. Lengths are
+// centimeters or inches.
+func (q Quantity) In(system System) Quantity {
+	var to Unit
+	switch q.Unit.Dimension {
+	case Mass:
+		grams := q.Value * q.Unit.base
+		switch {
+		case system == Imperial && grams >= Pound.base:
+			to = Pound
+		case system == Imperial:
+			to = Ounce
+		case grams >= Kilogram.base:
+			to = Kilogram
+		default:
+			to = Gram
+		}
+	case Volume:
+		ml := q.Value * q.Unit.base
+		switch {
+		case system == Imperial && (q.Unit.IsKitchenMeasure() || ml < Cup.base/4):
+			k, _ := q.Kitchen()
+			return k
+		case system == Imperial:
+			to = FluidOunce
+		case ml >= Liter.base:
+			to = Liter
+		default:
+			to = Milliliter
+		}
+	case Length:
+		if system == Imperial {
+			to = Inch
+		} else {
+			to = Centimeter
+		}
+	default:
+		return q
+	}
+	converted, _ := q.Convert(to)
+	return converted.Round()
+}
+
+// String formats q as in "2.5 tbsp".
+func (q Quantity) String() string {
+	return fmt.Sprintf("%s %s", formatValue(q.Value), q.Unit.Name)
+}
+
+// FeetAndInches formats a length as in "5 ft 9 in", rounded to the inch.
+func FeetAndInches(q Quantity) (string, error) {
+	in, err := q.Convert(Inch)
+	if err != nil {
+		return "", err
+	}
+	inches := int(math.Round(in.Value))
+	return fmt.Sprintf("%d ft %d in", inches/12, inches%12), nil
+}
+
+func formatValue(v float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", v), "0"), ".")
+}
@@ -0,0 +1,200 @@
+package units
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Quantity
+		to   Unit
+		want float64
+	}{
+		{"kg to g", Quantity{1.5, Kilogram}, Gram, 1500},
+		{"lb to oz", Quantity{1, Pound}, Ounce, 16},
+		{"g to oz", Quantity{28.349523125, Gram}, Ounce, 1},
+		{"cup to tbsp", Quantity{1, Cup}, Tablespoon, 16},
+		{"tbsp to tsp", Quantity{1, Tablespoon}, Teaspoon, 3},
+		{"cup to fl oz", Quantity{1, Cup}, FluidOunce, 8},
+		{"l to ml", Quantity{0.25, Liter}, Milliliter, 250},
+		{"ft to in", Quantity{6, Foot}, Inch, 72},
+		{"in to cm", Quantity{1, Inch}, Centimeter, 2.54},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.q.Convert(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Unit != tt.to || !approx(got.Value, tt.want) {
+				t.Errorf("Convert = %v, want %v %s", got, tt.want, tt.to.Name)
+			}
+		})
+	}
+
+	if _, err := (Quantity{1, Cup}).Convert(Gram); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Convert cup to g error = %v, want ErrIncompatible", err)
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		q    Quantity
+		want float64
+	}{
+		{Quantity{152.4, Gram}, 152},
+		{Quantity{7.46, Gram}, 7.5},
+		{Quantity{0.04, Gram}, 0},
+		{Quantity{5.29, Ounce}, 5.3},
+		{Quantity{1.3, Cup}, 1.25},
+		{Quantity{1.2, Tablespoon}, 1},
+		{Quantity{0.3, Teaspoon}, 0.25},
+		{Quantity{1.734, Meter}, 1.73},
+		{Quantity{-7.46, Gram}, -7.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.q.String(), func(t *testing.T) {
+			got := tt.q.Round()
+			if got.Unit != tt.q.Unit || got.Value != tt.want {
+				t.Errorf("Round = %v, want %v %s", got, tt.want, tt.q.Unit.Name)
+			}
+		})
+	}
+}
+
+func TestKitchen(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Quantity
+		want string
+	}{
+		{"cup stays cup", Quantity{1, Cup}, "1 cup"},
+		{"half of a quarter cup", Quantity{0.125, Cup}, "2 tbsp"},
+		{"quarter cup", Quantity{4, Tablespoon}, "0.25 cup"},
+		{"spoons", Quantity{1.5, Tablespoon}, "1.5 tbsp"},
+		{"under a tablespoon", Quantity{2, Teaspoon}, "2 tsp"},
+		{"metric", Quantity{5, Milliliter}, "1 tsp"},
+		{"pinch", Quantity{0.1, Milliliter}, "0.25 tsp"},
+		{"zero", Quantity{0, Cup}, "0 tsp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.q.Kitchen()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Kitchen = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Quantity{100, Gram}).Kitchen(); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("Kitchen of grams error = %v, want ErrIncompatible", err)
+	}
+}
+
+func TestIn(t *testing.T) {
+	tests := []struct {
+		name   string
+		q      Quantity
+		system System
+		want   string
+	}{
+		{"grams in metric", Quantity{150, Gram}, Metric, "150 g"},
+		{"kilograms in metric", Quantity{2.5, Pound}, Metric, "1.1 kg"},
+		{"ounces in imperial", Quantity{150, Gram}, Imperial, "5.3 oz"},
+		{"pounds in imperial", Quantity{1, Kilogram}, Imperial, "2.2 lb"},
+		{"milliliters in metric", Quantity{1, Cup}, Metric, "237 ml"},
+		{"liters in metric", Quantity{1500, Milliliter}, Metric, "1.5 l"},
+		{"drink in imperial", Quantity{500, Milliliter}, Imperial, "16.9 fl oz"},
+		{"cup in imperial", Quantity{1, Cup}, Imperial, "1 cup"},
+		{"small amount in imperial", Quantity{15, Milliliter}, Imperial, "1 tbsp"},
+		{"centimeters in imperial", Quantity{175, Centimeter}, Imperial, "69 in"},
+		{"inches in metric", Quantity{69, Inch}, Metric, "175 cm"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.In(tt.system); got.String() != tt.want {
+				t.Errorf("In(%s) = %v, want %s", tt.system, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s         string
+		wantUnit  Unit
+		wantToken string
+		wantRest  string
+		wantOK    bool
+	}{
+		{"g", Gram, "g", "", true},
+		{"Grams", Gram, "Grams", "", true},
+		{"cup milk", Cup, "cup", "milk", true},
+		{"fl oz orange juice", FluidOunce, "fl oz", "orange juice", true},
+		{"tbsp. olive oil", Tablespoon, "tbsp.", "olive oil", true},
+		{"slices", Unit{}, "", "", false},
+		{"", Unit{}, "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			u, token, rest, ok := Parse(tt.s)
+			if u != tt.wantUnit || token != tt.wantToken || rest != tt.wantRest || ok != tt.wantOK {
+				t.Errorf("Parse(%q) = %v, %q, %q, %v; want %v, %q, %q, %v",
+					tt.s, u.Name, token, rest, ok, tt.wantUnit.Name, tt.wantToken, tt.wantRest, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDensity(t *testing.T) {
+	tests := []struct {
+		food   string
+		want   float64
+		wantOK bool
+	}{
+		{"milk", 1.03, true},
+		{"Oatmeal with banana", 1.0, true},
+		{"peanut butter", 1.08, true},
+		{"peanuts", 0.6, true},
+		{"olive oil", 0.91, true},
+		{"brown sugar", 0.93, true},
+		{"sugar", 0.85, true},
+		{"buttermilk pancakes", 1.03, true},
+		{"oilseed", 0, false},
+		{"steak", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.food, func(t *testing.T) {
+			got, ok := Density(tt.food)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Density(%q) = %v, %v; want %v, %v", tt.food, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestToMass(t *testing.T) {
+	got, ok := ToMass(Quantity{1, Cup}, "milk")
+	if !ok || got.Unit != Gram || math.Round(got.Value) != 244 {
+		t.Errorf("ToMass(1 cup milk) = %v, %v; want 244 g", got, ok)
+	}
+	if got, ok := ToMass(Quantity{2, Ounce}, "steak"); !ok || !approx(got.Value, 56.69904625) {
+		t.Errorf("ToMass(2 oz steak) = %v, %v; want 56.7 g", got, ok)
+	}
+	if _, ok := ToMass(Quantity{1, Cup}, "steak"); ok {
+		t.Error("ToMass of a food without density reported ok")
+	}
+	if _, ok := ToMass(Quantity{1, Inch}, "milk"); ok {
+		t.Error("ToMass of a length reported ok")
+	}
+}